@@ -0,0 +1,224 @@
+// Package aggregatetest provides Given/When/Then helpers for testing event sourced goro.Aggregates. The
+// scenarios run through a goro.Repository backed by a memory.Store, and compare Events semantically: by
+// type and decoded payload, ignoring ids, versions and timestamps.
+//
+//	aggregatetest.New(t, newAccount, aggregatetest.WithRegistry(registry)).
+//		Given(Opened{Owner: "me"}).
+//		When(func(a goro.Aggregate) error { return a.(*Account).Deposit(10) }).
+//		Then(Deposited{Amount: 10})
+package aggregatetest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vectorhacker/goro"
+	"github.com/vectorhacker/goro/memory"
+)
+
+const aggregateID = "aggregate"
+
+// TestingT is the subset of testing.TB used by a Scenario
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+	FailNow()
+}
+
+// Command executes a command on an Aggregate
+type Command func(aggregate goro.Aggregate) error
+
+// Scenario describes the Events an Aggregate starts from, a Command executed on it, and its expected outcome
+type Scenario struct {
+	t        TestingT
+	factory  goro.AggregateFactory
+	registry *goro.EventRegistry
+	given    []interface{}
+	command  Command
+}
+
+// Option applies options to a Scenario
+type Option func(*Scenario)
+
+// WithRegistry makes a Scenario accept values of the types registered in registry in place of goro.Events,
+// and compare the payloads of Events by decoding them
+func WithRegistry(registry *goro.EventRegistry) Option {
+	return func(s *Scenario) {
+		s.registry = registry
+	}
+}
+
+// New creates a Scenario for the Aggregates created by factory
+func New(t TestingT, factory goro.AggregateFactory, options ...Option) *Scenario {
+	s := &Scenario{
+		t:       t,
+		factory: factory,
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+
+	return s
+}
+
+// Given sets the Events the Aggregate is loaded from. Events can be goro.Events, or values of the types
+// in the registry of the Scenario.
+func (s *Scenario) Given(events ...interface{}) *Scenario {
+	s.given = events
+	return s
+}
+
+// When sets the Command executed on the Aggregate
+func (s *Scenario) When(command Command) *Scenario {
+	s.command = command
+	return s
+}
+
+// Then runs the Scenario and checks that the Command succeeded and produced the expected Events, in order
+func (s *Scenario) Then(expected ...interface{}) {
+	s.t.Helper()
+
+	events, err := s.run()
+	if err != nil {
+		s.t.Errorf("expected the command to succeed, but it failed with: %v", err)
+		return
+	}
+
+	expectedEvents := s.encode(expected)
+	assert.Equal(s.t, s.normalize(expectedEvents), s.normalize(events), "unexpected events")
+}
+
+// ThenError runs the Scenario and checks that the Command failed with expected, as reported by errors.Is
+func (s *Scenario) ThenError(expected error) {
+	s.t.Helper()
+
+	events, err := s.run()
+	if err == nil {
+		s.t.Errorf("expected the command to fail with %q, but it succeeded with events:\n%s", expected, s.describe(events))
+		return
+	}
+
+	if !errors.Is(err, expected) {
+		s.t.Errorf("expected the command to fail with %q, but it failed with %q", expected, err)
+	}
+}
+
+// run writes the given Events, executes the Command through a Repository, and returns the Events it wrote
+func (s *Scenario) run() (goro.Events, error) {
+	s.t.Helper()
+
+	ctx := context.Background()
+	store := memory.NewStore()
+
+	given := s.encode(s.given)
+	if len(given) > 0 {
+		if err := store.Writer(aggregateID).Write(ctx, goro.ExpectedVersionNone, given...); err != nil {
+			s.t.Errorf("failed to write the given events: %v", err)
+			s.t.FailNow()
+		}
+	}
+
+	if s.command == nil {
+		s.t.Errorf("no command, call When before Then")
+		s.t.FailNow()
+	}
+
+	err := store.Repository(s.factory).Update(ctx, aggregateID, s.command)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := store.FowardsReader(aggregateID).Read(ctx, int64(len(given)), math.MaxInt32)
+	if errors.Is(err, goro.ErrStreamNotFound) {
+		return goro.Events{}, nil
+	}
+	if err != nil {
+		s.t.Errorf("failed to read the written events: %v", err)
+		s.t.FailNow()
+	}
+
+	return events, nil
+}
+
+// encode turns values into Events using the registry of the Scenario
+func (s *Scenario) encode(values []interface{}) goro.Events {
+	s.t.Helper()
+
+	events := make(goro.Events, len(values))
+	for i, v := range values {
+		if event, ok := v.(goro.Event); ok {
+			events[i] = event
+			continue
+		}
+
+		if s.registry == nil {
+			s.t.Errorf("%T is not a goro.Event, use WithRegistry to encode values", v)
+			s.t.FailNow()
+		}
+
+		event, err := s.registry.Encode(v)
+		if err != nil {
+			s.t.Errorf("failed to encode %#v: %v", v, err)
+			s.t.FailNow()
+		}
+		events[i] = event
+	}
+
+	return events
+}
+
+// normalized is the part of an Event that is compared
+type normalized struct {
+	Type string
+	Data interface{}
+}
+
+// normalize keeps the type and payload of Events, decoded with the registry of the Scenario when possible,
+// or as generic json otherwise
+func (s *Scenario) normalize(events goro.Events) []normalized {
+	result := make([]normalized, len(events))
+	for i, event := range events {
+		result[i] = normalized{
+			Type: event.Type,
+			Data: s.decode(event),
+		}
+	}
+
+	return result
+}
+
+func (s *Scenario) decode(event goro.Event) interface{} {
+	if s.registry != nil {
+		if v, err := s.registry.Decode(event); err == nil {
+			return v
+		}
+	}
+
+	var v interface{}
+	if !event.Binary && json.Unmarshal(event.Data, &v) == nil {
+		return v
+	}
+
+	return []byte(event.Data)
+}
+
+func (s *Scenario) describe(events goro.Events) string {
+	description := ""
+	for _, event := range s.normalize(events) {
+		description += "\t" + event.Type + ": " + describeValue(event.Data) + "\n"
+	}
+
+	return description
+}
+
+func describeValue(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "<unprintable>"
+	}
+
+	return string(b)
+}
@@ -0,0 +1,160 @@
+package aggregatetest_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vectorhacker/goro"
+	"github.com/vectorhacker/goro/aggregatetest"
+)
+
+var errInsufficientFunds = errors.New("insufficient funds")
+
+type deposited struct {
+	Amount int `json:"amount"`
+}
+
+type withdrawn struct {
+	Amount int `json:"amount"`
+}
+
+type account struct {
+	registry *goro.EventRegistry
+	version  int64
+	balance  int
+	changes  goro.Events
+}
+
+func (a *account) Apply(event goro.Event) {
+	v, _ := a.registry.Decode(event)
+	switch e := v.(type) {
+	case deposited:
+		a.balance += e.Amount
+	case withdrawn:
+		a.balance -= e.Amount
+	}
+	a.version = event.Version
+}
+
+func (a *account) Changes() goro.Events {
+	return a.changes
+}
+
+func (a *account) Version() int64 {
+	return a.version
+}
+
+func (a *account) withdraw(amount int) error {
+	if amount > a.balance {
+		return fmt.Errorf("withdrawing %d: %w", amount, errInsufficientFunds)
+	}
+
+	event, err := a.registry.Encode(withdrawn{Amount: amount})
+	if err != nil {
+		return err
+	}
+	a.changes = append(a.changes, event)
+	return nil
+}
+
+func withdraw(amount int) aggregatetest.Command {
+	return func(aggregate goro.Aggregate) error {
+		return aggregate.(*account).withdraw(amount)
+	}
+}
+
+type recorder struct {
+	errors []string
+	failed bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) FailNow() {
+	r.failed = true
+	panic(r)
+}
+
+func TestScenario(t *testing.T) {
+	registry := goro.NewEventRegistry()
+	registry.Register("deposited", deposited{})
+	registry.Register("withdrawn", withdrawn{})
+	newAccount := func(id string) goro.Aggregate {
+		return &account{registry: registry, version: goro.ExpectedVersionNone}
+	}
+
+	t.Run("it should pass when the expected events are written", func(t *testing.T) {
+		aggregatetest.New(t, newAccount, aggregatetest.WithRegistry(registry)).
+			Given(deposited{Amount: 10}, deposited{Amount: 5}).
+			When(withdraw(12)).
+			Then(withdrawn{Amount: 12})
+	})
+
+	t.Run("it should compare goro.Events semantically", func(t *testing.T) {
+		aggregatetest.New(t, newAccount, aggregatetest.WithRegistry(registry)).
+			Given(goro.CreateEvent("deposited", []byte("{\"amount\":10}"), nil, 0)).
+			When(withdraw(10)).
+			Then(goro.CreateEvent("withdrawn", []byte("{ \"amount\": 10 }"), []byte("{\"note\":\"ignored\"}"), 3))
+	})
+
+	t.Run("it should pass when the expected error is returned", func(t *testing.T) {
+		aggregatetest.New(t, newAccount, aggregatetest.WithRegistry(registry)).
+			Given(deposited{Amount: 10}).
+			When(withdraw(12)).
+			ThenError(errInsufficientFunds)
+	})
+
+	t.Run("it should report unexpected events with a diff", func(t *testing.T) {
+		r := &recorder{}
+		aggregatetest.New(r, newAccount, aggregatetest.WithRegistry(registry)).
+			Given(deposited{Amount: 10}).
+			When(withdraw(5)).
+			Then(withdrawn{Amount: 6})
+
+		assert.Len(t, r.errors, 1)
+		assert.Contains(t, r.errors[0], "unexpected events")
+		assert.Contains(t, r.errors[0], "-   Amount: (int) 6")
+		assert.Contains(t, r.errors[0], "+   Amount: (int) 5")
+	})
+
+	t.Run("it should report an unexpected error", func(t *testing.T) {
+		r := &recorder{}
+		aggregatetest.New(r, newAccount, aggregatetest.WithRegistry(registry)).
+			When(withdraw(5)).
+			Then(withdrawn{Amount: 5})
+
+		assert.Len(t, r.errors, 1)
+		assert.Contains(t, r.errors[0], "insufficient funds")
+	})
+
+	t.Run("it should report unexpected success", func(t *testing.T) {
+		r := &recorder{}
+		aggregatetest.New(r, newAccount, aggregatetest.WithRegistry(registry)).
+			Given(deposited{Amount: 10}).
+			When(withdraw(5)).
+			ThenError(errInsufficientFunds)
+
+		assert.Len(t, r.errors, 1)
+		assert.Contains(t, r.errors[0], "withdrawn: {\"amount\":5}")
+	})
+
+	t.Run("it should fail when a value can't be encoded", func(t *testing.T) {
+		r := &recorder{}
+		func() {
+			defer func() { recover() }()
+			aggregatetest.New(r, newAccount).
+				Given(deposited{Amount: 10}).
+				When(withdraw(5)).
+				Then()
+		}()
+
+		assert.True(t, r.failed)
+		assert.Contains(t, r.errors[0], "WithRegistry")
+	})
+}
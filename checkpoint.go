@@ -0,0 +1,114 @@
+package goro
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+const checkpointEventType = "checkpoint"
+
+// Checkpoint stores the position of the last Event processed from a stream, so that processing can resume
+// after it
+type Checkpoint interface {
+	// Load returns the saved position, or ExpectedVersionNone if nothing was processed yet
+	Load(ctx context.Context) (int64, error)
+	// Save stores a position
+	Save(ctx context.Context, position int64) error
+}
+
+// MemoryCheckpoint is a Checkpoint kept in memory
+type MemoryCheckpoint struct {
+	mu       sync.Mutex
+	position int64
+}
+
+// NewMemoryCheckpoint creates an empty MemoryCheckpoint
+func NewMemoryCheckpoint() *MemoryCheckpoint {
+	return &MemoryCheckpoint{
+		position: ExpectedVersionNone,
+	}
+}
+
+// Load implements the Checkpoint interface
+func (c *MemoryCheckpoint) Load(ctx context.Context) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.position, nil
+}
+
+// Save implements the Checkpoint interface
+func (c *MemoryCheckpoint) Save(ctx context.Context, position int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.position = position
+	return nil
+}
+
+type checkpointData struct {
+	Position int64 `json:"position"`
+}
+
+type streamCheckpoint struct {
+	streams Streams
+	stream  string
+
+	mu      sync.Mutex
+	limited bool
+}
+
+// NewStreamCheckpoint creates a Checkpoint that is saved as the last Event of a stream. The stream only
+// keeps its last Event.
+func NewStreamCheckpoint(streams Streams, stream string) Checkpoint {
+	return &streamCheckpoint{
+		streams: streams,
+		stream:  stream,
+	}
+}
+
+// Load implements the Checkpoint interface
+func (c *streamCheckpoint) Load(ctx context.Context) (int64, error) {
+	events, err := c.streams.BackwardsReader(c.stream).Read(ctx, StreamHead, 1)
+	if errors.Is(err, ErrStreamNotFound) || (err == nil && len(events) == 0) {
+		return ExpectedVersionNone, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	data := checkpointData{}
+	if err := json.Unmarshal(events[0].Data, &data); err != nil {
+		return 0, err
+	}
+
+	return data.Position, nil
+}
+
+// Save implements the Checkpoint interface
+func (c *streamCheckpoint) Save(ctx context.Context, position int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.limited {
+		err := c.streams.SetStreamMetadata(ctx, c.stream, StreamMetadata{
+			MaxCount: 1,
+		})
+		if err != nil {
+			return err
+		}
+		c.limited = true
+	}
+
+	data, err := json.Marshal(checkpointData{
+		Position: position,
+	})
+	if err != nil {
+		return err
+	}
+
+	event := CreateEvent(checkpointEventType, data, nil, 0)
+	return c.streams.Writer(c.stream).Write(ctx, ExpectedVersionAny, event)
+}
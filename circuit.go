@@ -0,0 +1,167 @@
+package goro
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dghubble/sling"
+)
+
+// CircuitState is the state of a CircuitBreaker
+type CircuitState int
+
+// CircuitState enum
+const (
+	// CircuitClosed lets every request through
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails every request with ErrCircuitOpen
+	CircuitOpen
+	// CircuitHalfOpen lets a single request through, to probe whether Event Store is back
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreaker stops a Client from sending requests to an Event Store that is down. After threshold
+// consecutive failures the circuit opens, and requests fail fast with ErrCircuitOpen. Once cooldown has
+// passed the circuit is half-open: a single request is let through, and the circuit closes if it
+// succeeds, or opens again if it fails. Connection errors and 5xx statuses count as failures.
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewCircuitBreaker creates a closed CircuitBreaker
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		threshold = 1
+	}
+
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// WithCircuitBreaker sends every request of the Client through a CircuitBreaker. The same CircuitBreaker
+// can be shared by several Clients of an Event Store, and its State used in health checks.
+func WithCircuitBreaker(breaker *CircuitBreaker) ClientOption {
+	return func(c *Client) {
+		if breaker == nil {
+			c.invalid("the circuit breaker is nil")
+			return
+		}
+		c.breaker = breaker
+	}
+}
+
+// State returns the current state of the circuit
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen && time.Since(b.openedAt) >= b.cooldown {
+		return CircuitHalfOpen
+	}
+
+	return b.state
+}
+
+// allow reports whether a request can be sent, and whether it's the probe of a half-open circuit
+func (b *CircuitBreaker) allow() (bool, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitClosed:
+		return true, false
+	case CircuitOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false, false
+		}
+		b.state = CircuitHalfOpen
+		fallthrough
+	default:
+		if b.probing {
+			return false, false
+		}
+		b.probing = true
+		return true, true
+	}
+}
+
+// record updates the circuit with the outcome of a request
+func (b *CircuitBreaker) record(probe, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		b.probing = false
+	}
+
+	if !failed {
+		b.failures = 0
+		b.state = CircuitClosed
+		return
+	}
+
+	b.failures++
+	if probe || b.failures >= b.threshold {
+		b.state = CircuitOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// release lets another request probe a half-open circuit, when a probe ends without an outcome
+func (b *CircuitBreaker) release(probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		b.probing = false
+	}
+}
+
+// breakerDoer is a sling.Doer that sends requests through a CircuitBreaker
+type breakerDoer struct {
+	doer    sling.Doer
+	breaker *CircuitBreaker
+}
+
+// Do implements the sling.Doer interface
+func (d breakerDoer) Do(req *http.Request) (*http.Response, error) {
+	allowed, probe := d.breaker.allow()
+	if !allowed {
+		return nil, ErrCircuitOpen
+	}
+
+	res, err := d.doer.Do(req)
+	if err != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		// the caller gave up, which says nothing about Event Store
+		d.breaker.release(probe)
+		return res, err
+	}
+
+	d.breaker.record(probe, err != nil || res.StatusCode >= http.StatusInternalServerError)
+	return res, err
+}
@@ -0,0 +1,105 @@
+package goro_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vectorhacker/goro"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	newServer := func(t *testing.T, status *int32, requests *int32) *httptest.Server {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(requests, 1)
+			w.WriteHeader(int(atomic.LoadInt32(status)))
+		}))
+		t.Cleanup(s.Close)
+		return s
+	}
+	write := func(client *goro.Client) error {
+		return client.Writer("tests").Write(ctx, goro.ExpectedVersionAny, goro.CreateEvent("test", []byte("{}"), nil, 0))
+	}
+
+	t.Run("it should open after the failure threshold", func(t *testing.T) {
+		status, requests := int32(http.StatusServiceUnavailable), int32(0)
+		s := newServer(t, &status, &requests)
+		breaker := goro.NewCircuitBreaker(3, time.Minute)
+		client := goro.Connect(s.URL, goro.WithCircuitBreaker(breaker))
+
+		for i := 0; i < 3; i++ {
+			assert.True(t, errors.Is(write(client), goro.ErrUnavailable))
+		}
+		assert.Equal(t, goro.CircuitOpen, breaker.State())
+
+		assert.Equal(t, goro.ErrCircuitOpen, write(client))
+		_, err := client.FowardsReader("tests").Read(ctx, 0, 1)
+		assert.Equal(t, goro.ErrCircuitOpen, err)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("it should not count client errors", func(t *testing.T) {
+		status, requests := int32(http.StatusNotFound), int32(0)
+		s := newServer(t, &status, &requests)
+		breaker := goro.NewCircuitBreaker(1, time.Minute)
+		client := goro.Connect(s.URL, goro.WithCircuitBreaker(breaker))
+
+		assert.True(t, errors.Is(write(client), goro.ErrStreamNotFound))
+		assert.Equal(t, goro.CircuitClosed, breaker.State())
+	})
+
+	t.Run("it should close after a successful probe", func(t *testing.T) {
+		status, requests := int32(http.StatusServiceUnavailable), int32(0)
+		s := newServer(t, &status, &requests)
+		breaker := goro.NewCircuitBreaker(1, 10*time.Millisecond)
+		client := goro.Connect(s.URL, goro.WithCircuitBreaker(breaker))
+
+		assert.NotNil(t, write(client))
+		assert.Equal(t, goro.CircuitOpen, breaker.State())
+
+		time.Sleep(20 * time.Millisecond)
+		assert.Equal(t, goro.CircuitHalfOpen, breaker.State())
+		atomic.StoreInt32(&status, http.StatusCreated)
+
+		assert.Nil(t, write(client))
+		assert.Equal(t, goro.CircuitClosed, breaker.State())
+	})
+
+	t.Run("it should open again after a failed probe", func(t *testing.T) {
+		status, requests := int32(http.StatusServiceUnavailable), int32(0)
+		s := newServer(t, &status, &requests)
+		breaker := goro.NewCircuitBreaker(2, 10*time.Millisecond)
+		client := goro.Connect(s.URL, goro.WithCircuitBreaker(breaker))
+
+		assert.NotNil(t, write(client))
+		assert.NotNil(t, write(client))
+		time.Sleep(20 * time.Millisecond)
+
+		assert.True(t, errors.Is(write(client), goro.ErrUnavailable))
+		assert.Equal(t, goro.CircuitOpen, breaker.State())
+		assert.Equal(t, goro.ErrCircuitOpen, write(client))
+	})
+
+	t.Run("it should fail subscriptions fast", func(t *testing.T) {
+		status, requests := int32(http.StatusServiceUnavailable), int32(0)
+		s := newServer(t, &status, &requests)
+		breaker := goro.NewCircuitBreaker(1, time.Minute)
+		client := goro.Connect(s.URL, goro.WithCircuitBreaker(breaker))
+		assert.NotNil(t, write(client))
+
+		message := <-client.CatchupSubscription("tests", 0).Subscribe(ctx)
+		assert.Equal(t, goro.ErrCircuitOpen, message.Error)
+	})
+
+	t.Run("it should name its states", func(t *testing.T) {
+		assert.Equal(t, "closed", goro.CircuitClosed.String())
+		assert.Equal(t, "open", goro.CircuitOpen.String())
+		assert.Equal(t, "half-open", goro.CircuitHalfOpen.String())
+	})
+}
@@ -1,19 +1,57 @@
 package goro
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"time"
 
 	"github.com/dghubble/sling"
 )
 
 // Client is a connection to an event store
 type Client struct {
-	sling *sling.Sling
+	sling      *sling.Sling
+	compressor *Compressor
+	httpClient *http.Client
+	tlsConfig  *tls.Config
+	err        error
+
+	noRedirects    bool
+	retryPolicy    *RetryPolicy
+	breaker        *CircuitBreaker
+	middleware     []Middleware
+	tracing        Tracer
+	collector      MetricsCollector
+	log            Logger
+	gossipSeeds    []string
+	gossipInterval time.Duration
+	followerReads  bool
 }
 
 // ClientOption applies options to a client
 type ClientOption func(*Client)
 
+// invalid records an error found in an option
+func (c *Client) invalid(format string, args ...interface{}) {
+	c.err = errors.Join(c.err, fmt.Errorf("%w: "+format, append([]interface{}{ErrInvalidOption}, args...)...))
+}
+
+// ensureTLS returns the TLS configuration of the Client, creating it if it's needed
+func (c *Client) ensureTLS() *tls.Config {
+	if c.tlsConfig == nil {
+		c.tlsConfig = &tls.Config{}
+	}
+
+	return c.tlsConfig
+}
+
 // WithBasicAuth adds basic authentication to the Event Store
 func WithBasicAuth(username, password string) ClientOption {
 	return func(c *Client) {
@@ -24,12 +62,84 @@ func WithBasicAuth(username, password string) ClientOption {
 // WithHTTPClient sets the http.Client for the Client
 func WithHTTPClient(httpClient *http.Client) ClientOption {
 	return func(c *Client) {
-		c.sling.Client(httpClient)
+		c.httpClient = httpClient
 	}
 }
 
-// Connect creates a new client
-func Connect(host string, options ...ClientOption) *Client {
+// WithCompression compresses the data of Events larger than threshold bytes when they are written by the
+// Writers of the Client. The Readers and Subscribers of every Client decompress compressed Events, so
+// consumers don't need this option to read them.
+func WithCompression(compression Compression, threshold int) ClientOption {
+	return func(c *Client) {
+		c.compressor = NewCompressor(compression, threshold)
+	}
+}
+
+// WithCABundle trusts the certificates of a PEM encoded CA bundle, in addition to the system ones
+func WithCABundle(bundle []byte) ClientOption {
+	return func(c *Client) {
+		config := c.ensureTLS()
+		if config.RootCAs == nil {
+			pool, err := x509.SystemCertPool()
+			if err != nil {
+				pool = x509.NewCertPool()
+			}
+			config.RootCAs = pool
+		}
+
+		if !config.RootCAs.AppendCertsFromPEM(bundle) {
+			c.invalid("the CA bundle has no certificates")
+		}
+	}
+}
+
+// WithCAFile trusts the certificates of a PEM encoded CA bundle file, in addition to the system ones
+func WithCAFile(path string) ClientOption {
+	return func(c *Client) {
+		bundle, err := os.ReadFile(path)
+		if err != nil {
+			c.invalid("reading the CA bundle: %v", err)
+			return
+		}
+
+		WithCABundle(bundle)(c)
+	}
+}
+
+// WithClientCertificate authenticates the Client with a certificate, for mutual TLS. The certificate and
+// its key are read from PEM encoded files.
+func WithClientCertificate(certFile, keyFile string) ClientOption {
+	return func(c *Client) {
+		certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			c.invalid("loading the client certificate: %v", err)
+			return
+		}
+
+		config := c.ensureTLS()
+		config.Certificates = append(config.Certificates, certificate)
+	}
+}
+
+// WithInsecureSkipVerify disables the verification of the certificate of the Event Store. It should only
+// be used in development.
+func WithInsecureSkipVerify() ClientOption {
+	return func(c *Client) {
+		c.ensureTLS().InsecureSkipVerify = true
+	}
+}
+
+// WithServerName sets the name the certificate of the Event Store is verified against, when it differs
+// from the host the Client connects to
+func WithServerName(serverName string) ClientOption {
+	return func(c *Client) {
+		c.ensureTLS().ServerName = serverName
+	}
+}
+
+// NewClient creates a new client, and validates its host and options. Invalid ones fail with an error that
+// wraps ErrInvalidOption.
+func NewClient(host string, options ...ClientOption) (*Client, error) {
 	c := &Client{
 		sling: sling.New().Base(host),
 	}
@@ -37,6 +147,109 @@ func Connect(host string, options ...ClientOption) *Client {
 		opt(c)
 	}
 
+	u, err := url.Parse(host)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		c.invalid("the host %q is not an http or https url", host)
+	} else if c.tlsConfig != nil && u.Scheme != "https" {
+		c.invalid("TLS options need an https host")
+	}
+
+	httpClient := c.httpClient
+	if c.tlsConfig != nil {
+		httpClient, err = withTLS(httpClient, c.tlsConfig)
+		if err != nil {
+			c.invalid("%v", err)
+		}
+	}
+
+	if len(c.middleware) > 0 {
+		httpClient = withMiddleware(httpClient, c.middleware)
+	}
+
+	known := newKnownHosts(c.gossipSeeds...)
+	if u != nil {
+		known.add(u.Host)
+	}
+
+	var doer sling.Doer = withoutRedirects(httpClient)
+	if !c.noRedirects {
+		doer = redirectDoer{doer: doer, known: known}
+	}
+
+	if len(c.gossipSeeds) > 0 && u != nil {
+		if c.gossipInterval <= 0 {
+			c.gossipInterval = defaultGossipInterval
+		}
+
+		doer = &cluster{
+			doer:          doer,
+			seeds:         append([]string{u.Host}, c.gossipSeeds...),
+			scheme:        u.Scheme,
+			interval:      c.gossipInterval,
+			followerReads: c.followerReads,
+			known:         known,
+			metrics:       metricsOf(c),
+			logger:        loggerOf(c),
+		}
+	}
+	if c.retryPolicy != nil {
+		doer = retryDoer{doer: doer, policy: *c.retryPolicy, logger: loggerOf(c)}
+	}
+	if c.breaker != nil {
+		doer = breakerDoer{doer: doer, breaker: c.breaker}
+	}
+	c.sling.Doer(doer)
+
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	return c, nil
+}
+
+// withTLS copies an http.Client to use a TLS configuration
+func withTLS(httpClient *http.Client, config *tls.Config) (*http.Client, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	var transport *http.Transport
+	switch t := httpClient.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return nil, fmt.Errorf("TLS options can't be applied to a %T transport", t)
+	}
+	transport.TLSClientConfig = config
+
+	copied := *httpClient
+	copied.Transport = transport
+	return &copied, nil
+}
+
+// failingDoer fails every request with an error
+type failingDoer struct {
+	err error
+}
+
+func (d failingDoer) Do(*http.Request) (*http.Response, error) {
+	return nil, d.err
+}
+
+// Connect creates a new client, without reporting invalid options. When the host or an option is invalid,
+// such as a missing CA file, Connect still returns a Client, but every request it makes fails with an error
+// that wraps ErrInvalidOption, and none of its other options apply. The mistake only shows up at the first
+// request, so prefer NewClient, which returns the error upfront. Connect is kept for compatibility.
+func Connect(host string, options ...ClientOption) *Client {
+	c, err := NewClient(host, options...)
+	if err != nil {
+		return &Client{
+			sling: sling.New().Base(host).Doer(failingDoer{err: err}),
+		}
+	}
+
 	return c
 }
 
@@ -45,29 +258,104 @@ func (c Client) Sling() *sling.Sling {
 	return c.sling.New()
 }
 
+// tracer returns the Tracer set with WithTracer
+func (c Client) tracer() Tracer {
+	return c.tracing
+}
+
+// metrics returns the MetricsCollector set with WithMetrics
+func (c Client) metrics() MetricsCollector {
+	return c.collector
+}
+
+// logger returns the Logger set with WithLogger
+func (c Client) logger() Logger {
+	return c.log
+}
+
 // Writer creates a new Writer for a stream
 func (c Client) Writer(stream string) Writer {
+	if c.compressor != nil {
+		return NewCompressingWriter(c.compressor, NewWriter(c, stream))
+	}
+
 	return NewWriter(c, stream)
 }
 
 // BackwardsReader creates a new Reader that reads backwards on a stream
 func (c Client) BackwardsReader(stream string) Reader {
-	return NewBackwardsReader(c, stream)
+	return NewDecompressingReader(NewBackwardsReader(c, stream))
 }
 
 // FowardsReader creates a new Reader that reads forwards on a stream
 func (c Client) FowardsReader(stream string) Reader {
-	return NewForwardsReader(c, stream)
+	return NewDecompressingReader(NewForwardsReader(c, stream))
 }
 
 // CatchupSubscription creates a new catchup style subscription that
 // starts reading at an event number and continues forwards
 func (c Client) CatchupSubscription(stream string, start int64) Subscriber {
-	return NewCatchupSubscription(c, stream, start)
+	return NewDecompressingSubscriber(NewCatchupSubscription(c, stream, start))
 }
 
 // PersistentSubscription creates a new competing consumer style subscription
-// with the given settings
+// with the given settings. It joins the subscription when it already exists.
 func (c Client) PersistentSubscription(stream, subscriptionName string, settings PersistentSubscriptionSettings) (Subscriber, error) {
-	return NewPersistentSubscription(c, stream, subscriptionName, settings)
+	return c.PersistentSubscriptionContext(context.Background(), stream, subscriptionName, settings)
+}
+
+// PersistentSubscriptionContext is PersistentSubscription with options, which creates the subscription
+// with ctx. Pass FailIfExists to fail with ErrConflict when the subscription already exists.
+func (c Client) PersistentSubscriptionContext(ctx context.Context, stream, subscriptionName string, settings PersistentSubscriptionSettings, options ...PersistentSubscriptionOption) (Subscriber, error) {
+	subscription, err := NewPersistentSubscriptionContext(ctx, c, stream, subscriptionName, settings, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewDecompressingSubscriber(subscription), nil
+}
+
+// Repository creates a new Repository for the Aggregates created by factory
+func (c Client) Repository(factory AggregateFactory, options ...RepositoryOption) *Repository {
+	return NewRepository(c, factory, append([]RepositoryOption{WithStreams(c)}, options...)...)
+}
+
+// SetStreamMetadata replaces the metadata of a stream
+func (c Client) SetStreamMetadata(ctx context.Context, stream string, metadata StreamMetadata) error {
+	return SetStreamMetadata(ctx, c, stream, metadata)
+}
+
+// StreamMetadata reads the metadata of a stream
+func (c Client) StreamMetadata(ctx context.Context, stream string) (StreamMetadata, error) {
+	return ReadStreamMetadata(ctx, c, stream)
+}
+
+// DeleteStream soft or hard deletes a stream
+func (c Client) DeleteStream(ctx context.Context, stream string, hard bool) error {
+	return DeleteStream(ctx, c, stream, hard)
+}
+
+// Export writes the Events of a stream to w as JSON Lines
+func (c Client) Export(ctx context.Context, stream string, w io.Writer, options ...ExportOption) error {
+	return Export(ctx, c, stream, w, options...)
+}
+
+// Import writes the Events of a JSON Lines export to a stream
+func (c Client) Import(ctx context.Context, r io.Reader, stream string, options ...ImportOption) error {
+	return Import(ctx, r, c, stream, options...)
+}
+
+// Ping checks that Event Store is up and handling requests
+func (c Client) Ping(ctx context.Context) error {
+	return Ping(ctx, c)
+}
+
+// Info reads the version, state and features of Event Store
+func (c Client) Info(ctx context.Context) (Info, error) {
+	return ReadInfo(ctx, c)
+}
+
+// Stats reads the statistics of Event Store, like the lengths of its queues and the usage of its disks
+func (c Client) Stats(ctx context.Context) (Stats, error) {
+	return ReadStats(ctx, c)
 }
@@ -1,9 +1,22 @@
 package goro_test
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/vectorhacker/goro"
@@ -56,3 +69,144 @@ func TestClient(t *testing.T) {
 		assert.NotNil(t, subscription)
 	})
 }
+
+// writePEM writes a PEM block to a file in dir and returns its path
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	path := filepath.Join(dir, name)
+	assert.Nil(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600))
+	return path
+}
+
+// generateCertificate creates a self signed client certificate and writes it and its key to dir
+func generateCertificate(t *testing.T, dir string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "goro"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+
+	return writePEM(t, dir, "client.crt", "CERTIFICATE", der), writePEM(t, dir, "client.key", "EC PRIVATE KEY", keyDER)
+}
+
+func TestTLS(t *testing.T) {
+	ctx := context.Background()
+	newServer := func() *httptest.Server {
+		return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		}))
+	}
+	write := func(c *goro.Client) error {
+		return c.Writer("tests").Write(ctx, goro.ExpectedVersionAny, goro.CreateEvent("test", []byte("{}"), nil, 0))
+	}
+
+	t.Run("it should trust a CA bundle", func(t *testing.T) {
+		s := newServer()
+		defer s.Close()
+		ca := writePEM(t, t.TempDir(), "ca.pem", "CERTIFICATE", s.Certificate().Raw)
+
+		c, err := goro.NewClient(s.URL, goro.WithCAFile(ca))
+		assert.Nil(t, err)
+		assert.Nil(t, write(c))
+
+		c, err = goro.NewClient(s.URL)
+		assert.Nil(t, err)
+		assert.NotNil(t, write(c))
+	})
+
+	t.Run("it should verify the server name", func(t *testing.T) {
+		s := newServer()
+		defer s.Close()
+		ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw})
+
+		c, err := goro.NewClient(s.URL, goro.WithCABundle(ca), goro.WithServerName("example.com"))
+		assert.Nil(t, err)
+		assert.Nil(t, write(c))
+
+		c, err = goro.NewClient(s.URL, goro.WithCABundle(ca), goro.WithServerName("eventstore.example.org"))
+		assert.Nil(t, err)
+		assert.NotNil(t, write(c))
+	})
+
+	t.Run("it should skip verification", func(t *testing.T) {
+		s := newServer()
+		defer s.Close()
+
+		c, err := goro.NewClient(s.URL, goro.WithInsecureSkipVerify())
+		assert.Nil(t, err)
+		assert.Nil(t, write(c))
+	})
+
+	t.Run("it should authenticate with a client certificate", func(t *testing.T) {
+		s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Len(t, r.TLS.PeerCertificates, 1)
+			assert.Equal(t, "goro", r.TLS.PeerCertificates[0].Subject.CommonName)
+			w.WriteHeader(http.StatusCreated)
+		}))
+		s.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+		s.StartTLS()
+		defer s.Close()
+		certFile, keyFile := generateCertificate(t, t.TempDir())
+
+		c, err := goro.NewClient(s.URL, goro.WithInsecureSkipVerify(), goro.WithClientCertificate(certFile, keyFile))
+		assert.Nil(t, err)
+		assert.Nil(t, write(c))
+
+		c, err = goro.NewClient(s.URL, goro.WithInsecureSkipVerify())
+		assert.Nil(t, err)
+		assert.NotNil(t, write(c))
+	})
+
+	t.Run("it should keep the settings of an http.Client", func(t *testing.T) {
+		s := newServer()
+		defer s.Close()
+		httpClient := &http.Client{Timeout: time.Second, Transport: &http.Transport{}}
+
+		c, err := goro.NewClient(s.URL, goro.WithHTTPClient(httpClient), goro.WithInsecureSkipVerify())
+		assert.Nil(t, err)
+		assert.Nil(t, write(c))
+		config := httpClient.Transport.(*http.Transport).TLSClientConfig
+		assert.True(t, config == nil || !config.InsecureSkipVerify)
+	})
+}
+
+func TestNewClient(t *testing.T) {
+	dir := t.TempDir()
+	invalid := writePEM(t, dir, "invalid.pem", "CERTIFICATE", []byte("not a certificate"))
+
+	cases := map[string][]goro.ClientOption{
+		"a missing CA bundle":            {goro.WithCAFile(filepath.Join(dir, "missing.pem"))},
+		"an empty CA bundle":             {goro.WithCAFile(invalid)},
+		"a missing client certificate":   {goro.WithClientCertificate(filepath.Join(dir, "missing.crt"), filepath.Join(dir, "missing.key"))},
+		"TLS with a custom RoundTripper": {goro.WithHTTPClient(&http.Client{Transport: goro.RoundTripperFunc(nil)}), goro.WithInsecureSkipVerify()},
+	}
+	for name, options := range cases {
+		t.Run("it should reject "+name, func(t *testing.T) {
+			_, err := goro.NewClient("https://localhost:2113", options...)
+			assert.True(t, errors.Is(err, goro.ErrInvalidOption), "%v", err)
+		})
+	}
+
+	t.Run("it should reject invalid hosts", func(t *testing.T) {
+		_, err := goro.NewClient("localhost:2113")
+		assert.True(t, errors.Is(err, goro.ErrInvalidOption))
+
+		_, err = goro.NewClient("http://localhost:2113", goro.WithInsecureSkipVerify())
+		assert.True(t, errors.Is(err, goro.ErrInvalidOption))
+	})
+
+	t.Run("it should fail the requests of a Client connected with invalid options", func(t *testing.T) {
+		c := goro.Connect("https://localhost:2113", goro.WithCAFile(filepath.Join(dir, "missing.pem")))
+
+		_, err := c.FowardsReader("tests").Read(context.Background(), 0, 1)
+		assert.True(t, errors.Is(err, goro.ErrInvalidOption))
+	})
+}
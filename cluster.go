@@ -0,0 +1,296 @@
+package goro
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dghubble/sling"
+)
+
+const (
+	gossipPath            = "/gossip"
+	defaultGossipInterval = 30 * time.Second
+)
+
+// member is a node of an Event Store cluster as it's described by the gossip endpoint. Event Store 20
+// and later describe the http endpoint of a node as httpEndPointIp and httpEndPointPort, earlier versions
+// as externalHttpIp and externalHttpPort.
+type member struct {
+	State            string `json:"state"`
+	IsAlive          bool   `json:"isAlive"`
+	ExternalHTTPIP   string `json:"externalHttpIp"`
+	ExternalHTTPPort int    `json:"externalHttpPort"`
+	HTTPEndPointIP   string `json:"httpEndPointIp"`
+	HTTPEndPointPort int    `json:"httpEndPointPort"`
+}
+
+func (m member) host() string {
+	if m.HTTPEndPointIP != "" {
+		return fmt.Sprintf("%s:%d", m.HTTPEndPointIP, m.HTTPEndPointPort)
+	}
+
+	return fmt.Sprintf("%s:%d", m.ExternalHTTPIP, m.ExternalHTTPPort)
+}
+
+func (m member) isLeader() bool {
+	return m.State == "Leader" || m.State == "Master"
+}
+
+func (m member) isFollower() bool {
+	return m.State == "Follower" || m.State == "Slave"
+}
+
+type gossip struct {
+	Members []member `json:"members"`
+}
+
+// cluster is a sling.Doer that sends requests to the nodes of an Event Store cluster, which it discovers
+// by polling the gossip endpoint of its seeds. Writes and persistent subscriptions go to the leader;
+// reads go to the leader too, unless followerReads is set.
+type cluster struct {
+	doer          sling.Doer
+	seeds         []string
+	scheme        string
+	interval      time.Duration
+	followerReads bool
+	known         *knownHosts
+	metrics       MetricsCollector
+	logger        Logger
+
+	mu         sync.Mutex
+	leader     string
+	followers  []string
+	next       int
+	discovered time.Time
+	// discovery is the discovery in progress, which other requests wait for
+	discovery *discovery
+}
+
+// discovery is a discovery of the cluster that requests share
+type discovery struct {
+	done chan struct{}
+	err  error
+}
+
+// WithGossipSeeds discovers the nodes of an Event Store cluster through the gossip endpoint of the seeds,
+// which are host:port addresses, and sends each request to a suitable node. The host the Client connects
+// to is a seed too. The cluster is rediscovered periodically, and whenever a node can't be reached, isn't
+// the leader anymore or is unavailable.
+func WithGossipSeeds(seeds ...string) ClientOption {
+	return func(c *Client) {
+		c.gossipSeeds = append(c.gossipSeeds, seeds...)
+	}
+}
+
+// WithGossipInterval sets how often the nodes of the cluster are rediscovered. The default is 30 seconds.
+func WithGossipInterval(interval time.Duration) ClientOption {
+	return func(c *Client) {
+		c.gossipInterval = interval
+	}
+}
+
+// WithFollowerReads sends reads to the followers of the cluster, spreading the load over them. Since
+// followers can lag behind the leader, reads might not see the latest writes.
+func WithFollowerReads() ClientOption {
+	return func(c *Client) {
+		c.followerReads = true
+	}
+}
+
+// toLeader reports whether a request must be sent to the leader
+func toLeader(req *http.Request) bool {
+	return req.Method != http.MethodGet || strings.HasPrefix(req.URL.Path, "/subscriptions/")
+}
+
+// Do implements the sling.Doer interface. If the node can't be reached, redirects the request or is
+// unavailable, the cluster is rediscovered and the request is sent again once. Requests that fail to
+// reach the node are only sent again when they are idempotent, since the node might have processed them.
+func (c *cluster) Do(req *http.Request) (*http.Response, error) {
+	host, err := c.node(req.Context(), toLeader(req))
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.doer.Do(c.rewrite(req, host))
+	if !stale(res, err) || req.Context().Err() != nil {
+		return res, err
+	}
+
+	c.forget()
+	if err != nil && !isIdempotent(req) {
+		return res, err
+	}
+
+	host, rediscoverErr := c.node(req.Context(), toLeader(req))
+	if rediscoverErr != nil {
+		return res, err
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return res, err
+	}
+
+	reason := err
+	if res != nil {
+		reason = relevantError(res)
+		res.Body.Close()
+	}
+
+	c.metrics.Reconnected()
+	c.logger.WarnContext(req.Context(), "reconnecting to another node", "method", req.Method, "path", req.URL.Path, "node", host, "error", reason)
+	return c.doer.Do(c.rewrite(req, host))
+}
+
+// stale reports whether a request reached a node that can't serve it anymore, because it can't be
+// reached, isn't the leader or is unavailable
+func stale(res *http.Response, err error) bool {
+	return err != nil || isRedirect(res.StatusCode) || res.StatusCode == http.StatusServiceUnavailable
+}
+
+// rewrite copies a request to send it to another node
+func (c *cluster) rewrite(req *http.Request, host string) *http.Request {
+	r := req.Clone(req.Context())
+	r.URL.Scheme = c.scheme
+	r.URL.Host = host
+	r.Host = ""
+	if req.GetBody != nil {
+		r.Body, _ = req.GetBody()
+	}
+
+	return r
+}
+
+// forget drops the known nodes, so that the cluster is rediscovered before the next request
+func (c *cluster) forget() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.discovered = time.Time{}
+}
+
+// node returns the host of the node to send a request to. The cluster is discovered without holding the
+// lock, and requests that need it rediscovered at the same time share a single discovery.
+func (c *cluster) node(ctx context.Context, leader bool) (string, error) {
+	c.mu.Lock()
+	for time.Since(c.discovered) > c.interval {
+		if d := c.discovery; d != nil {
+			c.mu.Unlock()
+			select {
+			case <-ctx.Done():
+				return "", ctx.Err()
+			case <-d.done:
+			}
+
+			// the discovery of a cancelled request is retried, otherwise its error is shared
+			if d.err != nil && !errors.Is(d.err, context.Canceled) && !errors.Is(d.err, context.DeadlineExceeded) {
+				return "", d.err
+			}
+			c.mu.Lock()
+			continue
+		}
+
+		d := &discovery{done: make(chan struct{})}
+		c.discovery = d
+		candidates := append(append([]string{c.leader}, c.followers...), c.seeds...)
+		c.mu.Unlock()
+
+		leader, followers, err := c.discover(ctx, candidates)
+
+		c.mu.Lock()
+		if err == nil {
+			if leader != c.leader {
+				c.logger.InfoContext(ctx, "discovered the leader of the cluster", "leader", leader, "followers", len(followers))
+			}
+			c.leader = leader
+			c.followers = followers
+			c.discovered = time.Now()
+		}
+		c.discovery = nil
+		d.err = err
+		close(d.done)
+		if err != nil {
+			c.mu.Unlock()
+			return "", err
+		}
+	}
+	defer c.mu.Unlock()
+
+	if leader || !c.followerReads || len(c.followers) == 0 {
+		return c.leader, nil
+	}
+
+	c.next = (c.next + 1) % len(c.followers)
+	return c.followers[c.next], nil
+}
+
+// discover asks the candidates, the known nodes then the seeds, for the members of the cluster, and returns
+// its leader and followers
+func (c *cluster) discover(ctx context.Context, candidates []string) (string, []string, error) {
+	for _, candidate := range candidates {
+		if candidate == "" {
+			continue
+		}
+
+		members, err := c.gossip(ctx, candidate)
+		if err != nil {
+			continue
+		}
+
+		leader, followers := "", []string{}
+		for _, m := range members {
+			switch {
+			case !m.IsAlive:
+			case m.isLeader():
+				leader = m.host()
+			case m.isFollower():
+				followers = append(followers, m.host())
+			}
+		}
+		if leader == "" {
+			continue
+		}
+
+		c.known.add(leader)
+		c.known.add(followers...)
+		return leader, followers, nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", nil, err
+	}
+
+	return "", nil, ErrNoLeader
+}
+
+// gossip fetches the members of the cluster from a node
+func (c *cluster) gossip(ctx context.Context, host string) ([]member, error) {
+	u := url.URL{Scheme: c.scheme, Host: host, Path: gossipPath}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", jsonContentType)
+
+	res, err := c.doer.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("gossip from %s: %s", host, res.Status)
+	}
+
+	g := gossip{}
+	if err := json.NewDecoder(res.Body).Decode(&g); err != nil {
+		return nil, err
+	}
+
+	return g.Members, nil
+}
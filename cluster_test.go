@@ -0,0 +1,224 @@
+package goro_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vectorhacker/goro"
+	"github.com/vectorhacker/goro/estest"
+	"github.com/vectorhacker/goro/memory"
+)
+
+// fakeCluster is a cluster of nodes that share a memory.Store, and describe each other through a fake
+// gossip endpoint
+type fakeCluster struct {
+	mu       sync.Mutex
+	nodes    []*httptest.Server
+	states   map[string]string
+	requests map[string][]string
+	// statuses are the statuses nodes reply with instead of handling requests
+	statuses map[string]int
+	gossips  int
+}
+
+func newFakeCluster(t *testing.T, states ...string) *fakeCluster {
+	c := &fakeCluster{
+		states:   map[string]string{},
+		requests: map[string][]string{},
+		statuses: map[string]int{},
+	}
+	handler := estest.NewHandler(memory.NewStore())
+	t.Cleanup(handler.Close)
+
+	for _, state := range states {
+		var node *httptest.Server
+		node = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host := node.Listener.Addr().String()
+			if r.URL.Path == "/gossip" {
+				c.gossip(w)
+				return
+			}
+
+			c.mu.Lock()
+			c.requests[host] = append(c.requests[host], r.Method+" "+r.URL.Path)
+			status := c.statuses[host]
+			c.mu.Unlock()
+			if status != 0 {
+				w.WriteHeader(status)
+				return
+			}
+			handler.ServeHTTP(w, r)
+		}))
+		t.Cleanup(node.Close)
+
+		c.nodes = append(c.nodes, node)
+		c.states[node.Listener.Addr().String()] = state
+	}
+
+	return c
+}
+
+func (c *fakeCluster) gossip(w http.ResponseWriter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gossips++
+
+	members := []map[string]interface{}{}
+	for _, node := range c.nodes {
+		u, _ := url.Parse(node.URL)
+		port, _ := strconv.Atoi(u.Port())
+		members = append(members, map[string]interface{}{
+			"state":            c.states[u.Host],
+			"isAlive":          c.states[u.Host] != "Dead",
+			"externalHttpIp":   u.Hostname(),
+			"externalHttpPort": port,
+		})
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{"members": members})
+}
+
+func (c *fakeCluster) host(i int) string {
+	return c.nodes[i].Listener.Addr().String()
+}
+
+func (c *fakeCluster) setState(i int, state string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.states[c.host(i)] = state
+}
+
+func (c *fakeCluster) setStatus(i int, status int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.statuses[c.host(i)] = status
+}
+
+func (c *fakeCluster) gossipCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gossips
+}
+
+func (c *fakeCluster) requestsTo(i int) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string{}, c.requests[c.host(i)]...)
+}
+
+func TestCluster(t *testing.T) {
+	ctx := context.Background()
+	event := func() goro.Event {
+		return goro.CreateEvent("test", []byte("{}"), nil, 0)
+	}
+
+	t.Run("it should send requests to the leader", func(t *testing.T) {
+		c := newFakeCluster(t, "Follower", "Leader", "Follower")
+		client, err := goro.NewClient(c.nodes[0].URL, goro.WithGossipSeeds(c.host(2)))
+		assert.Nil(t, err)
+
+		assert.Nil(t, client.Writer("tests").Write(ctx, goro.ExpectedVersionAny, event()))
+		_, err = client.FowardsReader("tests").Read(ctx, 0, 1)
+		assert.Nil(t, err)
+
+		assert.Empty(t, c.requestsTo(0))
+		assert.Equal(t, []string{"POST /streams/tests", "GET /streams/tests/0/forward/1"}, c.requestsTo(1))
+		assert.Empty(t, c.requestsTo(2))
+	})
+
+	t.Run("it should send reads to followers", func(t *testing.T) {
+		c := newFakeCluster(t, "Follower", "Master", "Slave")
+		client, err := goro.NewClient(c.nodes[0].URL, goro.WithGossipSeeds(c.host(1)), goro.WithFollowerReads())
+		assert.Nil(t, err)
+
+		assert.Nil(t, client.Writer("tests").Write(ctx, goro.ExpectedVersionAny, event()))
+		for i := 0; i < 2; i++ {
+			_, err = client.FowardsReader("tests").Read(ctx, 0, 1)
+			assert.Nil(t, err)
+		}
+
+		assert.Equal(t, []string{"GET /streams/tests/0/forward/1"}, c.requestsTo(0))
+		assert.Equal(t, []string{"POST /streams/tests"}, c.requestsTo(1))
+		assert.Equal(t, []string{"GET /streams/tests/0/forward/1"}, c.requestsTo(2))
+	})
+
+	t.Run("it should rediscover the cluster when the leader fails", func(t *testing.T) {
+		c := newFakeCluster(t, "Follower", "Leader")
+		client := goro.Connect(c.nodes[0].URL, goro.WithGossipSeeds(c.host(1)))
+		assert.Nil(t, client.Writer("tests").Write(ctx, goro.ExpectedVersionAny, event()))
+
+		c.setState(0, "Leader")
+		c.setState(1, "Dead")
+		c.nodes[1].Close()
+
+		assert.Nil(t, client.Writer("tests").Write(ctx, goro.ExpectedVersionAny, event()))
+		assert.Equal(t, []string{"POST /streams/tests"}, c.requestsTo(0))
+	})
+
+	t.Run("it should not resend requests that aren't idempotent when the leader fails", func(t *testing.T) {
+		c := newFakeCluster(t, "Follower", "Leader")
+		client := goro.Connect(c.nodes[0].URL, goro.WithGossipSeeds(c.host(1)))
+		assert.Nil(t, client.Writer("tests").Write(ctx, goro.ExpectedVersionAny, event()))
+
+		c.setState(0, "Leader")
+		c.setState(1, "Dead")
+		c.nodes[1].Close()
+
+		_, err := client.PersistentSubscriptionContext(ctx, "tests", "group", goro.PersistentSubscriptionSettings{}, goro.FailIfExists())
+		assert.NotNil(t, err)
+		assert.Empty(t, c.requestsTo(0))
+
+		_, err = client.PersistentSubscriptionContext(ctx, "tests", "group", goro.PersistentSubscriptionSettings{}, goro.FailIfExists())
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"PUT /subscriptions/tests/group"}, c.requestsTo(0))
+	})
+
+	for _, status := range []int{http.StatusTemporaryRedirect, http.StatusServiceUnavailable} {
+		status := status
+		t.Run("it should rediscover the cluster when the leader replies with "+strconv.Itoa(status), func(t *testing.T) {
+			c := newFakeCluster(t, "Follower", "Leader")
+			client := goro.Connect(c.nodes[0].URL, goro.WithGossipSeeds(c.host(1)))
+			assert.Nil(t, client.Writer("tests").Write(ctx, goro.ExpectedVersionAny, event()))
+
+			c.setState(0, "Leader")
+			c.setState(1, "Follower")
+			c.setStatus(1, status)
+
+			assert.Nil(t, client.Writer("tests").Write(ctx, goro.ExpectedVersionAny, event()))
+			assert.Equal(t, []string{"POST /streams/tests"}, c.requestsTo(0))
+		})
+	}
+
+	t.Run("it should discover the cluster once for concurrent requests", func(t *testing.T) {
+		c := newFakeCluster(t, "Follower", "Leader")
+		client := goro.Connect(c.nodes[0].URL, goro.WithGossipSeeds(c.host(1)))
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.Nil(t, client.Writer("tests").Write(ctx, goro.ExpectedVersionAny, event()))
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 1, c.gossipCount())
+	})
+
+	t.Run("it should fail without a leader", func(t *testing.T) {
+		c := newFakeCluster(t, "Follower", "Follower")
+		client := goro.Connect(c.nodes[0].URL, goro.WithGossipSeeds(c.host(1)))
+
+		err := client.Writer("tests").Write(ctx, goro.ExpectedVersionAny, event())
+		assert.True(t, errors.Is(err, goro.ErrNoLeader), "%v", err)
+	})
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/vectorhacker/goro"
+)
+
+// newFlagSet creates the flags of a command
+func newFlagSet(env *environment, name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(env.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(env.stderr, "usage: goro %s\n", commands[name].usage)
+		flags.PrintDefaults()
+	}
+
+	return flags
+}
+
+// parseStream parses the flags of a command that takes a single stream argument
+func parseStream(flags *flag.FlagSet, args []string) (string, error) {
+	if err := flags.Parse(args); err != nil {
+		return "", err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return "", errors.New("expected a stream")
+	}
+
+	return flags.Arg(0), nil
+}
+
+func readCommand(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet(env, "read")
+	backwards := flags.Bool("backwards", false, "read from the newest Event to the oldest")
+	from := flags.Int64("from", -1, "the event number to start at, by default the start or head of the stream")
+	count := flags.Int("count", 20, "the number of Events to read")
+	stream, err := parseStream(flags, args)
+	if err != nil {
+		return err
+	}
+
+	reader := env.client.FowardsReader(stream)
+	start, n := *from, *count
+	switch {
+	case *backwards && start == 0:
+		// reading backwards from the first Event only reads it
+		if n > 1 {
+			n = 1
+		}
+	case *backwards:
+		// backwards reads begin after their start
+		reader = env.client.BackwardsReader(stream)
+		if start > 0 {
+			start--
+		}
+	case start < 0:
+		start = 0
+	}
+
+	events, err := reader.Read(ctx, start, n)
+	if err != nil {
+		return err
+	}
+
+	p := newPrinter(env.output, env.stdout)
+	for _, event := range events {
+		if err := p.Print(event); err != nil {
+			return err
+		}
+	}
+
+	return p.Flush()
+}
+
+func tailCommand(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet(env, "tail")
+	n := flags.Int("n", 10, "the number of Events to print")
+	follow := flags.Bool("f", false, "keep printing new Events as they are written")
+	stream, err := parseStream(flags, args)
+	if err != nil {
+		return err
+	}
+
+	events, err := env.client.BackwardsReader(stream).Read(ctx, goro.StreamHead, *n)
+	if err != nil && !(*follow && errors.Is(err, goro.ErrStreamNotFound)) {
+		return err
+	}
+
+	p := newPrinter(env.output, env.stdout)
+	next := int64(0)
+	for i := len(events) - 1; i >= 0; i-- {
+		if err := p.Print(events[i]); err != nil {
+			return err
+		}
+		// the Events of category streams are numbered by their position in it
+		next = events[i].Version + 1
+		if events[i].PositionStream != "" {
+			next = events[i].Position + 1
+		}
+	}
+	if err := p.Flush(); err != nil || !*follow {
+		return err
+	}
+
+	for message := range env.client.CatchupSubscription(stream, next).Subscribe(ctx) {
+		if message.Error != nil {
+			return message.Error
+		}
+
+		if err := p.Print(message.Event); err != nil {
+			return err
+		}
+		if err := p.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// input is an Event as it's read by the write command
+type input struct {
+	ID       string          `json:"eventId"`
+	Type     string          `json:"eventType"`
+	Data     json.RawMessage `json:"data"`
+	Metadata json.RawMessage `json:"metadata"`
+}
+
+func writeCommand(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet(env, "write")
+	file := flags.String("file", "-", "the file to read Events from, - for stdin")
+	eventType := flags.String("type", "", "write each json value read as the data of an Event of this type")
+	expectedVersion := flags.Int64("expected-version", goro.ExpectedVersionAny, "the expected version of the stream")
+	stream, err := parseStream(flags, args)
+	if err != nil {
+		return err
+	}
+
+	r := env.stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	events, err := decodeEvents(r, *eventType)
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		return errors.New("no events to write")
+	}
+
+	return env.client.Writer(stream).Write(ctx, *expectedVersion, events...)
+}
+
+// decodeEvents decodes a sequence of json values into Events. Unless eventType is set, the values are
+// objects with an eventType, data and optionally an eventId and metadata.
+func decodeEvents(r io.Reader, eventType string) (goro.Events, error) {
+	events := goro.Events{}
+	decoder := json.NewDecoder(r)
+	for {
+		var event goro.Event
+		if eventType != "" {
+			data := json.RawMessage{}
+			err := decoder.Decode(&data)
+			if err == io.EOF {
+				return events, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			event = goro.CreateEvent(eventType, data, nil, 0)
+		} else {
+			in := input{}
+			err := decoder.Decode(&in)
+			if err == io.EOF {
+				return events, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			if in.Type == "" {
+				return nil, fmt.Errorf("event %d has no eventType", len(events))
+			}
+
+			event = goro.CreateEvent(in.Type, in.Data, in.Metadata, 0)
+			if in.ID != "" {
+				if err := event.ID.UnmarshalText([]byte(in.ID)); err != nil {
+					return nil, fmt.Errorf("event %d: %v", len(events), err)
+				}
+			}
+		}
+
+		event.Version = int64(len(events))
+		events = append(events, event)
+	}
+}
+
+func metaCommand(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet(env, "meta")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	switch {
+	case flags.NArg() == 2 && flags.Arg(0) == "get":
+		metadata, err := env.client.StreamMetadata(ctx, flags.Arg(1))
+		if err != nil {
+			return err
+		}
+
+		return printMetadata(env.output, env.stdout, metadata)
+	case (flags.NArg() == 2 || flags.NArg() == 3) && flags.Arg(0) == "set":
+		var r io.Reader = env.stdin
+		if flags.NArg() == 3 {
+			r = strings.NewReader(flags.Arg(2))
+		}
+
+		metadata := goro.StreamMetadata{}
+		if err := json.NewDecoder(r).Decode(&metadata); err != nil {
+			return fmt.Errorf("invalid metadata: %v", err)
+		}
+
+		return env.client.SetStreamMetadata(ctx, flags.Arg(1), metadata)
+	default:
+		flags.Usage()
+		return errors.New("expected get or set and a stream")
+	}
+}
+
+func deleteCommand(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet(env, "delete")
+	hard := flags.Bool("hard", false, "delete the stream permanently")
+	stream, err := parseStream(flags, args)
+	if err != nil {
+		return err
+	}
+
+	return env.client.DeleteStream(ctx, stream, *hard)
+}
+
+func exportCommand(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet(env, "export")
+	file := flags.String("file", "-", "the file to export to, - for stdout")
+	resume := flags.Bool("resume", false, "continue an interrupted export to the file")
+	stream, err := parseStream(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if *file == "-" {
+		return env.client.Export(ctx, stream, env.stdout)
+	}
+
+	mode := os.O_RDWR | os.O_CREATE
+	if !*resume {
+		mode |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(*file, mode, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	last, size, err := goro.LastExported(f)
+	if err != nil {
+		return err
+	}
+	if err := f.Truncate(size); err != nil {
+		return err
+	}
+	if _, err := f.Seek(size, io.SeekStart); err != nil {
+		return err
+	}
+
+	if err := env.client.Export(ctx, stream, f, goro.WithExportStart(last+1)); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+func importCommand(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet(env, "import")
+	file := flags.String("file", "-", "the file to import from, - for stdin")
+	chunkSize := flags.Int("chunk-size", 100, "the number of Events written at a time")
+	stream, err := parseStream(flags, args)
+	if err != nil {
+		return err
+	}
+
+	r := env.stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	return env.client.Import(ctx, r, stream, goro.WithImportChunkSize(*chunkSize))
+}
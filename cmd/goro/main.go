@@ -0,0 +1,158 @@
+// Command goro inspects and edits Event Store streams from the command line.
+//
+//	goro [-host url] [-user name] [-password secret] [-output json|table] <command> [arguments]
+//
+// The commands are:
+//
+//	read    reads the Events of a stream, forwards or backwards
+//	tail    prints the last Events of a stream, and follows new ones with -f
+//	write   writes Events to a stream from a file or stdin
+//	meta    gets or sets the metadata of a stream
+//	delete  soft or hard deletes a stream
+//	export  exports a stream as JSON Lines
+//	import  imports a stream from JSON Lines
+//
+// The host and credentials default to the GORO_HOST, GORO_USER and GORO_PASSWORD environment variables.
+// TLS is configured with the -ca-file, -cert, -key and -insecure flags.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+
+	"github.com/vectorhacker/goro"
+)
+
+const defaultHost = "http://localhost:2113"
+
+// environment is what commands need to run
+type environment struct {
+	client *goro.Client
+	output string
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, env *environment, args []string) error
+}
+
+// commands is set in init, since the commands look up their own usage in it
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"read":   {"read [-backwards] [-from n] [-count n] <stream>", readCommand},
+		"tail":   {"tail [-n count] [-f] <stream>", tailCommand},
+		"write":  {"write [-file path] [-type eventType] [-expected-version n] <stream>", writeCommand},
+		"meta":   {"meta get <stream> | meta set <stream> [json]", metaCommand},
+		"delete": {"delete [-hard] <stream>", deleteCommand},
+		"export": {"export [-file path] [-resume] <stream>", exportCommand},
+		"import": {"import [-file path] [-chunk-size n] <stream>", importCommand},
+	}
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "goro: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func getenv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+
+	return fallback
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("goro", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	host := flags.String("host", getenv("GORO_HOST", defaultHost), "the url of the Event Store")
+	user := flags.String("user", os.Getenv("GORO_USER"), "the user to authenticate as")
+	password := flags.String("password", os.Getenv("GORO_PASSWORD"), "the password of the user")
+	output := flags.String("output", "table", "the output format, json or table")
+	caFile := flags.String("ca-file", "", "a PEM encoded CA bundle to trust")
+	certFile := flags.String("cert", "", "a PEM encoded client certificate, for mutual TLS")
+	keyFile := flags.String("key", "", "the PEM encoded key of the client certificate")
+	insecure := flags.Bool("insecure", false, "skip verifying the certificate of the Event Store")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: goro [flags] <command> [arguments]")
+		fmt.Fprintln(stderr, "\nflags:")
+		flags.PrintDefaults()
+		fmt.Fprintln(stderr, "\ncommands:")
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(stderr, "  %s\n", commands[name].usage)
+		}
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *output != "json" && *output != "table" {
+		return fmt.Errorf("unknown output format %q", *output)
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("no command")
+	}
+
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		flags.Usage()
+		return fmt.Errorf("unknown command %q", flags.Arg(0))
+	}
+
+	options := []goro.ClientOption{}
+	if *user != "" {
+		options = append(options, goro.WithBasicAuth(*user, *password))
+	}
+
+	if *caFile != "" {
+		options = append(options, goro.WithCAFile(*caFile))
+	}
+	if *certFile != "" || *keyFile != "" {
+		options = append(options, goro.WithClientCertificate(*certFile, *keyFile))
+	}
+	if *insecure {
+		options = append(options, goro.WithInsecureSkipVerify())
+	}
+
+	client, err := goro.NewClient(strings.TrimSuffix(*host, "/"), options...)
+	if err != nil {
+		return err
+	}
+
+	env := &environment{
+		client: client,
+		output: *output,
+		stdin:  stdin,
+		stdout: stdout,
+		stderr: stderr,
+	}
+
+	return cmd.run(ctx, env, flags.Args()[1:])
+}
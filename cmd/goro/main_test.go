@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vectorhacker/goro"
+	"github.com/vectorhacker/goro/estest"
+)
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	cli := func(s *estest.Server, stdin string, args ...string) (string, error) {
+		stdout := new(bytes.Buffer)
+		err := run(ctx, append([]string{"-host", s.URL}, args...), strings.NewReader(stdin), stdout, new(bytes.Buffer))
+		return stdout.String(), err
+	}
+
+	t.Run("it should write and read events", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+
+		_, err := cli(s, "{\"amount\":1}\n{\"amount\":2}\n{\"amount\":3}\n", "write", "-type", "deposit", "account-1")
+		assert.Nil(t, err)
+
+		out, err := cli(s, "", "-output", "json", "read", "-from", "1", "account-1")
+		assert.Nil(t, err)
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		assert.Len(t, lines, 2)
+
+		r := record{}
+		assert.Nil(t, json.Unmarshal([]byte(lines[0]), &r))
+		assert.Equal(t, "account-1", r.Stream)
+		assert.Equal(t, int64(1), r.Version)
+		assert.Equal(t, "deposit", r.Type)
+		assert.JSONEq(t, "{\"amount\":2}", string(r.Data))
+
+		out, err = cli(s, "", "read", "account-1")
+		assert.Nil(t, err)
+		assert.Contains(t, out, "NUMBER")
+		assert.Contains(t, out, "{\"amount\":3}")
+	})
+
+	t.Run("it should write events with their ids and metadata", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+
+		id := goro.NewUUID()
+		input := "{\"eventId\":\"" + id.String() + "\",\"eventType\":\"opened\",\"data\":{},\"metadata\":{\"by\":\"me\"}}"
+		_, err := cli(s, input, "write", "-expected-version", "-1", "account-1")
+		assert.Nil(t, err)
+
+		events, err := s.Connect().FowardsReader("account-1").Read(ctx, 0, 1)
+		assert.Nil(t, err)
+		assert.Equal(t, id, events[0].ID)
+		assert.JSONEq(t, "{\"by\":\"me\"}", string(events[0].Metadata))
+
+		_, err = cli(s, input, "write", "-expected-version", "-1", "account-2", "extra")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("it should tail and follow a stream", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+		client := s.Connect()
+		assert.Nil(t, client.Writer("account-1").Write(ctx, goro.ExpectedVersionNone, goro.CreateEvent("deposit", []byte("{\"amount\":1}"), nil, 0)))
+
+		ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+		stdout := &syncBuffer{}
+		done := make(chan error)
+		go func() {
+			done <- run(ctx, []string{"-host", s.URL, "-output", "json", "tail", "-f", "account-1"}, strings.NewReader(""), stdout, new(bytes.Buffer))
+		}()
+
+		assert.Nil(t, client.Writer("account-1").Write(ctx, 0, goro.CreateEvent("deposit", []byte("{\"amount\":2}"), nil, 1)))
+		assert.Eventually(t, func() bool {
+			return strings.Count(stdout.String(), "\n") == 2
+		}, time.Second, 10*time.Millisecond)
+		cancel()
+		<-done
+
+		assert.Contains(t, stdout.String(), "{\"amount\":2}")
+	})
+
+	t.Run("it should read backwards from an event", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+
+		_, err := cli(s, "{\"amount\":1}\n{\"amount\":2}\n{\"amount\":3}\n", "write", "-type", "deposit", "account-1")
+		assert.Nil(t, err)
+
+		versions := func(out string) []int64 {
+			versions := []int64{}
+			for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+				r := record{}
+				assert.Nil(t, json.Unmarshal([]byte(line), &r))
+				versions = append(versions, r.Version)
+			}
+			return versions
+		}
+
+		out, err := cli(s, "", "-output", "json", "read", "-backwards", "-from", "1", "-count", "5", "account-1")
+		assert.Nil(t, err)
+		assert.Equal(t, []int64{1, 0}, versions(out))
+
+		out, err = cli(s, "", "-output", "json", "read", "-backwards", "-from", "0", "-count", "5", "account-1")
+		assert.Nil(t, err)
+		assert.Equal(t, []int64{0}, versions(out))
+
+		out, err = cli(s, "", "-output", "json", "read", "-backwards", "-count", "2", "account-1")
+		assert.Nil(t, err)
+		assert.Equal(t, []int64{2, 1}, versions(out))
+	})
+
+	t.Run("it should tail and follow a category stream", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+		client := s.Connect()
+		assert.Nil(t, client.Writer("account-1").Write(ctx, goro.ExpectedVersionNone, goro.CreateEvent("deposit", []byte("{\"amount\":1}"), nil, 0), goro.CreateEvent("deposit", []byte("{\"amount\":2}"), nil, 1)))
+		assert.Nil(t, client.Writer("account-2").Write(ctx, goro.ExpectedVersionNone, goro.CreateEvent("deposit", []byte("{\"amount\":3}"), nil, 0)))
+
+		ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+		stdout := &syncBuffer{}
+		done := make(chan error)
+		go func() {
+			done <- run(ctx, []string{"-host", s.URL, "-output", "json", "tail", "-n", "1", "-f", "$ce-account"}, strings.NewReader(""), stdout, new(bytes.Buffer))
+		}()
+		assert.Eventually(t, func() bool {
+			return strings.Contains(stdout.String(), "{\"amount\":3}")
+		}, time.Second, 10*time.Millisecond)
+
+		assert.Nil(t, client.Writer("account-2").Write(ctx, 0, goro.CreateEvent("deposit", []byte("{\"amount\":4}"), nil, 1)))
+		assert.Eventually(t, func() bool {
+			return strings.Contains(stdout.String(), "{\"amount\":4}")
+		}, time.Second, 10*time.Millisecond)
+		cancel()
+		<-done
+
+		assert.Equal(t, 2, strings.Count(stdout.String(), "\n"))
+		assert.NotContains(t, stdout.String(), "{\"amount\":2}")
+	})
+
+	t.Run("it should get and set stream metadata", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+
+		_, err := cli(s, "", "meta", "set", "account-1", "{\"$maxCount\":3}")
+		assert.Nil(t, err)
+
+		out, err := cli(s, "", "-output", "json", "meta", "get", "account-1")
+		assert.Nil(t, err)
+		assert.JSONEq(t, "{\"$maxCount\":3}", out)
+
+		out, err = cli(s, "", "meta", "get", "account-1")
+		assert.Nil(t, err)
+		assert.Contains(t, out, "$maxCount  3")
+	})
+
+	t.Run("it should delete a stream", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+
+		_, err := cli(s, "{}", "write", "-type", "opened", "account-1")
+		assert.Nil(t, err)
+		_, err = cli(s, "", "delete", "-hard", "account-1")
+		assert.Nil(t, err)
+
+		_, err = cli(s, "", "read", "account-1")
+		assert.True(t, errors.Is(err, goro.ErrStreamDeleted), "%v", err)
+	})
+
+	t.Run("it should export and import streams", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+		file := filepath.Join(t.TempDir(), "account-1.jsonl")
+
+		_, err := cli(s, "{\"amount\":1}\n{\"amount\":2}\n", "write", "-type", "deposit", "account-1")
+		assert.Nil(t, err)
+		_, err = cli(s, "", "export", "-file", file, "account-1")
+		assert.Nil(t, err)
+
+		_, err = cli(s, "{\"amount\":3}\n", "write", "-type", "deposit", "account-1")
+		assert.Nil(t, err)
+		_, err = cli(s, "", "export", "-file", file, "-resume", "account-1")
+		assert.Nil(t, err)
+
+		exported, err := os.ReadFile(file)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, strings.Count(string(exported), "\n"))
+
+		_, err = cli(s, string(exported), "import", "account-2")
+		assert.Nil(t, err)
+		out, err := cli(s, "", "-output", "json", "read", "account-2")
+		assert.Nil(t, err)
+		assert.Equal(t, 3, strings.Count(out, "\n"))
+		assert.Contains(t, out, "{\"amount\":3}")
+	})
+
+	t.Run("it should reject unknown commands", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+
+		_, err := cli(s, "", "frobnicate")
+		assert.EqualError(t, err, "unknown command \"frobnicate\"")
+	})
+}
+
+// syncBuffer is a bytes.Buffer that can be written and read concurrently
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.String()
+}
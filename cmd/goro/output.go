@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/vectorhacker/goro"
+)
+
+// maxDataWidth is the number of characters of data shown in a table cell
+const maxDataWidth = 80
+
+// printer prints Events
+type printer interface {
+	Print(event goro.Event) error
+	Flush() error
+}
+
+func newPrinter(output string, w io.Writer) printer {
+	if output == "json" {
+		return &jsonPrinter{encoder: json.NewEncoder(w)}
+	}
+
+	return newTablePrinter(w)
+}
+
+// record is an Event as it's printed in json lines. Binary data is printed as a base64 string.
+type record struct {
+	Stream   string          `json:"stream"`
+	Version  int64           `json:"eventNumber"`
+	ID       uuid.UUID       `json:"eventId"`
+	Type     string          `json:"eventType"`
+	At       time.Time       `json:"created"`
+	Data     json.RawMessage `json:"data,omitempty"`
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+	Binary   bool            `json:"binary,omitempty"`
+}
+
+type jsonPrinter struct {
+	encoder *json.Encoder
+}
+
+func (p *jsonPrinter) Print(event goro.Event) error {
+	r := record{
+		Stream:   event.Stream,
+		Version:  event.Version,
+		ID:       event.ID,
+		Type:     event.Type,
+		At:       event.At,
+		Data:     event.Data,
+		Metadata: event.Metadata,
+		Binary:   event.Binary,
+	}
+	if event.Binary {
+		data, err := json.Marshal([]byte(event.Data))
+		if err != nil {
+			return err
+		}
+		r.Data = data
+	}
+
+	return p.encoder.Encode(r)
+}
+
+func (p *jsonPrinter) Flush() error {
+	return nil
+}
+
+type tablePrinter struct {
+	w      *tabwriter.Writer
+	header bool
+}
+
+func newTablePrinter(w io.Writer) *tablePrinter {
+	return &tablePrinter{w: tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)}
+}
+
+func (p *tablePrinter) Print(event goro.Event) error {
+	if !p.header {
+		p.header = true
+		if _, err := fmt.Fprintln(p.w, "NUMBER\tTYPE\tID\tCREATED\tDATA"); err != nil {
+			return err
+		}
+	}
+
+	data := string(event.Data)
+	if event.Binary {
+		data = fmt.Sprintf("<%d bytes>", len(event.Data))
+	}
+
+	_, err := fmt.Fprintf(p.w, "%d\t%s\t%s\t%s\t%s\n",
+		event.Version,
+		event.Type,
+		event.ID,
+		event.At.Format(time.RFC3339),
+		truncate(data, maxDataWidth),
+	)
+	return err
+}
+
+func (p *tablePrinter) Flush() error {
+	return p.w.Flush()
+}
+
+// truncate shortens s to width characters, and keeps it on a single line
+func truncate(s string, width int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > width {
+		return string(r[:width-3]) + "..."
+	}
+
+	return s
+}
+
+// printMetadata prints the metadata of a stream as a json object, or as a table of its settings
+func printMetadata(output string, w io.Writer, metadata goro.StreamMetadata) error {
+	if output == "json" {
+		return json.NewEncoder(w).Encode(metadata)
+	}
+
+	b, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	settings := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &settings); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "SETTING\tVALUE")
+	for _, key := range keys {
+		fmt.Fprintf(tw, "%s\t%s\n", key, settings[key])
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,134 @@
+package goro
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec serializes values into the data of Events. Codecs whose content type is not application/json
+// produce Binary Events.
+type Codec interface {
+	ContentType() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec is a Codec that encodes values as json
+type JSONCodec struct{}
+
+// ContentType implements the Codec interface
+func (JSONCodec) ContentType() string {
+	return jsonContentType
+}
+
+// Marshal implements the Codec interface
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal implements the Codec interface
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// ProtobufCodec is a Codec that encodes values using Protocol Buffers. Values must implement proto.Message.
+type ProtobufCodec struct{}
+
+// ContentType implements the Codec interface
+func (ProtobufCodec) ContentType() string {
+	return "application/x-protobuf"
+}
+
+// Marshal implements the Codec interface
+func (ProtobufCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, ErrNotProtoMessage
+	}
+
+	return proto.Marshal(m)
+}
+
+// Unmarshal implements the Codec interface
+func (ProtobufCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return ErrNotProtoMessage
+	}
+
+	return proto.Unmarshal(data, m)
+}
+
+// MessagePackCodec is a Codec that encodes values using MessagePack
+type MessagePackCodec struct{}
+
+// ContentType implements the Codec interface
+func (MessagePackCodec) ContentType() string {
+	return "application/x-msgpack"
+}
+
+// Marshal implements the Codec interface
+func (MessagePackCodec) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+// Unmarshal implements the Codec interface
+func (MessagePackCodec) Unmarshal(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+// rawDecoder is a sling.ResponseDecoder that reads the body of a response as is
+type rawDecoder struct{}
+
+func (rawDecoder) Decode(res *http.Response, v interface{}) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("can't decode a raw body into %T", v)
+	}
+
+	var err error
+	*b, err = io.ReadAll(res.Body)
+	return err
+}
+
+// resolveBinaryData replaces the data of the Binary Events embedded in a feed with their raw bytes, which
+// Event Store only serves one Event at a time.
+func resolveBinaryData(ctx context.Context, slinger Slinger, events Events) error {
+	for i, event := range events {
+		if !event.Binary {
+			continue
+		}
+
+		req, err := slinger.
+			Sling().
+			Get(fmt.Sprintf("/streams/%s/%d", event.Stream, event.Version)).
+			Set("Accept", binaryContentType).
+			Request()
+		if err != nil {
+			return err
+		}
+
+		req = req.WithContext(ctx)
+
+		data := []byte{}
+		res, err := slinger.Sling().ResponseDecoder(rawDecoder{}).Do(req, &data, nil)
+		if err != nil {
+			return err
+		}
+
+		err = relevantError(res)
+		if err != nil {
+			return err
+		}
+
+		events[i].Data = data
+	}
+
+	return nil
+}
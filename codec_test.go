@@ -0,0 +1,59 @@
+package goro_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vectorhacker/goro"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestCodecs(t *testing.T) {
+	t.Run("it should round trip json", func(t *testing.T) {
+		codec := goro.JSONCodec{}
+		data, err := codec.Marshal(deposited{Amount: 10})
+		assert.Nil(t, err)
+		assert.JSONEq(t, "{\"amount\":10}", string(data))
+
+		v := deposited{}
+		assert.Nil(t, codec.Unmarshal(data, &v))
+		assert.Equal(t, deposited{Amount: 10}, v)
+	})
+
+	t.Run("it should round trip protobuf", func(t *testing.T) {
+		codec := goro.ProtobufCodec{}
+		data, err := codec.Marshal(wrapperspb.String("hello"))
+		assert.Nil(t, err)
+
+		v := &wrapperspb.StringValue{}
+		assert.Nil(t, codec.Unmarshal(data, v))
+		assert.Equal(t, "hello", v.GetValue())
+
+		_, err = codec.Marshal(deposited{})
+		assert.Equal(t, goro.ErrNotProtoMessage, err)
+	})
+
+	t.Run("it should round trip message pack", func(t *testing.T) {
+		codec := goro.MessagePackCodec{}
+		data, err := codec.Marshal(deposited{Amount: 10})
+		assert.Nil(t, err)
+
+		v := deposited{}
+		assert.Nil(t, codec.Unmarshal(data, &v))
+		assert.Equal(t, deposited{Amount: 10}, v)
+	})
+
+	t.Run("it should encode binary events with a registry", func(t *testing.T) {
+		registry := goro.NewEventRegistry(goro.WithCodec(goro.ProtobufCodec{}))
+		registry.Register("greeted", &wrapperspb.StringValue{})
+
+		event, err := registry.Encode(wrapperspb.String("hello"))
+		assert.Nil(t, err)
+		assert.True(t, event.Binary)
+
+		v, err := registry.Decode(event)
+		assert.Nil(t, err)
+		assert.True(t, proto.Equal(wrapperspb.String("hello"), v.(proto.Message)))
+	})
+}
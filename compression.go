@@ -0,0 +1,171 @@
+package goro
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const compressionKey = "$goro.compression"
+
+// Compression is an algorithm used to compress the data of Events
+type Compression string
+
+// Compression enum
+const (
+	CompressionGzip Compression = "gzip"
+	CompressionZstd Compression = "zstd"
+)
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	zstdErr     error
+)
+
+func zstdCodec() (*zstd.Encoder, *zstd.Decoder, error) {
+	zstdOnce.Do(func() {
+		zstdEncoder, zstdErr = zstd.NewWriter(nil)
+		if zstdErr != nil {
+			return
+		}
+		zstdDecoder, zstdErr = zstd.NewReader(nil)
+	})
+
+	return zstdEncoder, zstdDecoder, zstdErr
+}
+
+// Compressor compresses the data of Events that are larger than a threshold. Compressed data is stored as
+// a base64 json string, and the Compression used is recorded in the Metadata of the Event, so that
+// decompressing Readers and Subscribers can decompress it.
+type Compressor struct {
+	compression Compression
+	threshold   int
+}
+
+// NewCompressor creates a Compressor that compresses data larger than threshold bytes
+func NewCompressor(compression Compression, threshold int) *Compressor {
+	return &Compressor{
+		compression: compression,
+		threshold:   threshold,
+	}
+}
+
+// Compress returns a copy of an Event with its data compressed, if it's larger than the threshold. Binary
+// Events are returned as is, since compressed data is stored as a json string.
+func (c *Compressor) Compress(event Event) (Event, error) {
+	if event.Binary || len(event.Data) <= c.threshold {
+		return event, nil
+	}
+
+	var compressed []byte
+	switch c.compression {
+	case CompressionGzip:
+		b := new(bytes.Buffer)
+		w := gzip.NewWriter(b)
+		if _, err := w.Write(event.Data); err != nil {
+			return Event{}, err
+		}
+		if err := w.Close(); err != nil {
+			return Event{}, err
+		}
+		compressed = b.Bytes()
+	case CompressionZstd:
+		encoder, _, err := zstdCodec()
+		if err != nil {
+			return Event{}, err
+		}
+		compressed = encoder.EncodeAll(event.Data, nil)
+	default:
+		return Event{}, ErrUnknownCompression
+	}
+
+	data, err := json.Marshal(base64.StdEncoding.EncodeToString(compressed))
+	if err != nil {
+		return Event{}, err
+	}
+
+	metadata, err := setMetadataValue(event.Metadata, compressionKey, c.compression)
+	if err != nil {
+		return Event{}, err
+	}
+
+	event.Data = data
+	event.Metadata = metadata
+	return event, nil
+}
+
+// Decompress returns a copy of an Event with its data decompressed, if it was compressed by a Compressor.
+// Events whose Metadata isn't a json object, or names a Compression goro doesn't know, are returned as is.
+func Decompress(event Event) (Event, error) {
+	var compression Compression
+	found, err := metadataValue(event.Metadata, compressionKey, &compression)
+	if err != nil || !found {
+		return event, nil
+	}
+	if compression != CompressionGzip && compression != CompressionZstd {
+		return event, nil
+	}
+
+	encoded := ""
+	if err := json.Unmarshal(event.Data, &encoded); err != nil {
+		return Event{}, err
+	}
+
+	compressed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return Event{}, err
+	}
+
+	var data []byte
+	switch compression {
+	case CompressionGzip:
+		r, err := gzip.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			return Event{}, err
+		}
+		data, err = io.ReadAll(r)
+		if err != nil {
+			return Event{}, err
+		}
+	case CompressionZstd:
+		_, decoder, err := zstdCodec()
+		if err != nil {
+			return Event{}, err
+		}
+		data, err = decoder.DecodeAll(compressed, nil)
+		if err != nil {
+			return Event{}, err
+		}
+	}
+
+	metadata, err := deleteMetadataValues(event.Metadata, compressionKey)
+	if err != nil {
+		return Event{}, err
+	}
+
+	event.Data = data
+	event.Metadata = metadata
+	return event, nil
+}
+
+// NewCompressingWriter creates a Writer that compresses large Events before writing them with another Writer
+func NewCompressingWriter(compressor *Compressor, writer Writer) Writer {
+	return NewMappingWriter(compressor.Compress, writer)
+}
+
+// NewDecompressingReader creates a Reader that decompresses the Events read by another Reader
+func NewDecompressingReader(reader Reader) Reader {
+	return NewMappingReader(Decompress, reader)
+}
+
+// NewDecompressingSubscriber creates a Subscriber that decompresses the Events of another Subscriber
+func NewDecompressingSubscriber(subscriber Subscriber) Subscriber {
+	return NewMappingSubscriber(Decompress, subscriber)
+}
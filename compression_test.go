@@ -0,0 +1,128 @@
+package goro_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dghubble/sling"
+	"github.com/gorilla/pat"
+	"github.com/stretchr/testify/assert"
+	"github.com/vectorhacker/goro"
+	"github.com/vectorhacker/goro/estest"
+)
+
+func TestCompressor(t *testing.T) {
+	large := []byte("{\"text\":\"" + strings.Repeat("trouble ", 100) + "\"}")
+
+	for _, compression := range []goro.Compression{goro.CompressionGzip, goro.CompressionZstd} {
+		compression := compression
+		t.Run("it should round trip "+string(compression), func(t *testing.T) {
+			compressor := goro.NewCompressor(compression, 100)
+			event := goro.CreateEvent("large", large, []byte("{\"user\":\"me\"}"), 0)
+
+			compressed, err := compressor.Compress(event)
+			assert.Nil(t, err)
+			assert.True(t, len(compressed.Data) < len(large))
+			assert.JSONEq(t, "{\"user\":\"me\",\"$goro.compression\":\""+string(compression)+"\"}", string(compressed.Metadata))
+
+			decompressed, err := goro.Decompress(compressed)
+			assert.Nil(t, err)
+			assert.Equal(t, []byte(large), []byte(decompressed.Data))
+			assert.JSONEq(t, "{\"user\":\"me\"}", string(decompressed.Metadata))
+		})
+	}
+
+	t.Run("it should leave small events alone", func(t *testing.T) {
+		compressor := goro.NewCompressor(goro.CompressionGzip, 100)
+		event := goro.CreateEvent("small", []byte("{\"double\":\"trouble\"}"), nil, 0)
+
+		compressed, err := compressor.Compress(event)
+		assert.Nil(t, err)
+		assert.Equal(t, event, compressed)
+	})
+
+	t.Run("it should fail on unknown compressions", func(t *testing.T) {
+		compressor := goro.NewCompressor("lzma", 0)
+
+		_, err := compressor.Compress(goro.CreateEvent("large", large, nil, 0))
+		assert.Equal(t, goro.ErrUnknownCompression, err)
+	})
+
+	t.Run("it should leave events it didn't compress alone", func(t *testing.T) {
+		for _, metadata := range []string{"\"a string\"", "[1,2]", "{\"contentEncoding\":\"utf-8\"}", "{\"$goro.compression\":\"lzma\"}"} {
+			event := goro.CreateEvent("test", []byte("{}"), []byte(metadata), 0)
+
+			decompressed, err := goro.Decompress(event)
+			assert.Nil(t, err, metadata)
+			assert.Equal(t, event, decompressed, metadata)
+		}
+	})
+
+	t.Run("it should decompress events when reading", func(t *testing.T) {
+		compressed, err := goro.NewCompressor(goro.CompressionZstd, 100).Compress(goro.CreateEvent("large", large, nil, 0))
+		assert.Nil(t, err)
+
+		mux := pat.New()
+		mux.Get("/streams/{stream}/{start}/{direction}/{pageSize}", func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"entries": goro.Events{compressed},
+			})
+		})
+		s := httptest.NewServer(mux)
+		defer s.Close()
+
+		r := goro.NewForwardsReader(goro.SlingerFunc(func() *sling.Sling {
+			return sling.New().Base(s.URL).Client(s.Client()).New()
+		}), "test")
+
+		events, err := r.Read(context.Background(), 0, 10)
+		assert.Nil(t, err)
+		assert.Len(t, events, 1)
+		assert.Equal(t, compressed.Data, events[0].Data)
+
+		events, err = goro.NewDecompressingReader(r).Read(context.Background(), 0, 10)
+		assert.Nil(t, err)
+		assert.Len(t, events, 1)
+		assert.Equal(t, []byte(large), []byte(events[0].Data))
+	})
+}
+
+func TestWithCompression(t *testing.T) {
+	ctx := context.Background()
+	large := []byte("{\"text\":\"" + strings.Repeat("trouble ", 100) + "\"}")
+
+	s := estest.NewServer()
+	defer s.Close()
+	client := s.Connect(goro.WithCompression(goro.CompressionGzip, 100))
+	assert.Nil(t, client.Writer("tests").Write(ctx, goro.ExpectedVersionNone, goro.CreateEvent("large", large, nil, 0)))
+
+	t.Run("it should decompress events read by any client", func(t *testing.T) {
+		events, err := client.FowardsReader("tests").Read(ctx, 0, 1)
+		assert.Nil(t, err)
+		assert.Len(t, events, 1)
+		assert.Equal(t, large, []byte(events[0].Data))
+
+		events, err = s.Connect().FowardsReader("tests").Read(ctx, 0, 1)
+		assert.Nil(t, err)
+		assert.Equal(t, large, []byte(events[0].Data))
+	})
+
+	t.Run("it should decompress events of persistent subscriptions", func(t *testing.T) {
+		subscription, err := client.PersistentSubscription("tests", "group", goro.PersistentSubscriptionSettings{})
+		assert.Nil(t, err)
+		subscription, err = goro.UpdatePersistentSubscription(subscription, goro.PersistentSubscriptionSettings{MaxRetryCount: 3})
+		assert.Nil(t, err)
+
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		message := <-subscription.Subscribe(ctx)
+		assert.Nil(t, message.Error)
+		assert.Equal(t, large, []byte(message.Event.Data))
+		assert.Nil(t, message.Ack())
+	})
+}
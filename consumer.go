@@ -0,0 +1,42 @@
+package goro
+
+import (
+	"context"
+)
+
+// Handler handles a StreamMessage. The context it receives carries the correlation and causation ids of
+// the Event, so that the Events it writes are linked to it.
+type Handler func(ctx context.Context, message StreamMessage) error
+
+// Consume handles the messages of a Subscriber one at a time until the subscription ends or ctx is done.
+// Messages with an Acknowledger are acknowledged once handled, and retried when the handler fails. Any
+// other failure stops Consume and is returned.
+func Consume(ctx context.Context, subscriber Subscriber, handler Handler) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for message := range subscriber.Subscribe(ctx) {
+		if message.Error != nil {
+			return message.Error
+		}
+
+		err := handler(EventContext(ctx, message.Event), message)
+		if message.Acknowledger == nil {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err != nil {
+			err = message.Nack(ActionRetry)
+		} else {
+			err = message.Ack()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return ctx.Err()
+}
@@ -0,0 +1,100 @@
+package goro
+
+import (
+	"context"
+	"encoding/json"
+)
+
+const (
+	correlationIDKey = "$correlationId"
+	causationIDKey   = "$causationId"
+)
+
+type contextKey string
+
+const (
+	correlationIDContextKey contextKey = "goro.correlationId"
+	causationIDContextKey   contextKey = "goro.causationId"
+)
+
+// WithCorrelationID returns a copy of ctx that carries the id of the workflow the written Events belong to
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDContextKey, id)
+}
+
+// CorrelationID returns the correlation id carried by ctx, if any
+func CorrelationID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(correlationIDContextKey).(string)
+	return id, ok
+}
+
+// WithCausationID returns a copy of ctx that carries the id of the Event that caused the written Events
+func WithCausationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, causationIDContextKey, id)
+}
+
+// CausationID returns the causation id carried by ctx, if any
+func CausationID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(causationIDContextKey).(string)
+	return id, ok
+}
+
+// EventContext returns a copy of ctx for handling an Event. The Event becomes the cause of the Events
+// written with it, which belong to the same workflow as the Event, or to a new one started by the Event.
+func EventContext(ctx context.Context, event Event) context.Context {
+	correlationID := ""
+	found, err := metadataValue(event.Metadata, correlationIDKey, &correlationID)
+	if err != nil || !found {
+		correlationID = event.ID.String()
+	}
+
+	ctx = WithCorrelationID(ctx, correlationID)
+	return WithCausationID(ctx, event.ID.String())
+}
+
+// Correlate returns a copy of an Event with the correlation and causation ids carried by ctx set in its
+// Metadata, unless it has them already.
+func Correlate(ctx context.Context, event Event) (Event, error) {
+	var err error
+	if correlationID, ok := CorrelationID(ctx); ok {
+		event.Metadata, err = setMissingMetadataValue(event.Metadata, correlationIDKey, correlationID)
+		if err != nil {
+			return Event{}, err
+		}
+	}
+
+	if causationID, ok := CausationID(ctx); ok {
+		event.Metadata, err = setMissingMetadataValue(event.Metadata, causationIDKey, causationID)
+		if err != nil {
+			return Event{}, err
+		}
+	}
+
+	return event, nil
+}
+
+// correlate correlates events in place
+func correlate(ctx context.Context, events Events) error {
+	for i, event := range events {
+		var err error
+		events[i], err = Correlate(ctx, event)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func setMissingMetadataValue(metadata json.RawMessage, key string, value interface{}) (json.RawMessage, error) {
+	existing := json.RawMessage{}
+	found, err := metadataValue(metadata, key, &existing)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		return metadata, nil
+	}
+
+	return setMetadataValue(metadata, key, value)
+}
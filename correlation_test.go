@@ -0,0 +1,145 @@
+package goro_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dghubble/sling"
+	"github.com/gorilla/pat"
+	"github.com/stretchr/testify/assert"
+	"github.com/vectorhacker/goro"
+	"github.com/vectorhacker/goro/estest"
+)
+
+type acknowledger struct {
+	acked  bool
+	nacked goro.Action
+}
+
+func (a *acknowledger) Ack() error {
+	a.acked = true
+	return nil
+}
+
+func (a *acknowledger) Nack(action goro.Action) error {
+	a.nacked = action
+	return nil
+}
+
+func TestCorrelation(t *testing.T) {
+	t.Run("it should write the correlation and causation ids in the metadata", func(t *testing.T) {
+		mux := pat.New()
+		mux.Post("/streams/{stream}", func(w http.ResponseWriter, r *http.Request) {
+			events := goro.Events{}
+			err := json.NewDecoder(r.Body).Decode(&events)
+			assert.Nil(t, err)
+			assert.Len(t, events, 2)
+
+			assert.JSONEq(t, "{\"$correlationId\":\"workflow\",\"$causationId\":\"cause\"}", string(events[0].Metadata))
+			assert.JSONEq(t, "{\"$correlationId\":\"other\",\"$causationId\":\"cause\",\"user\":\"me\"}", string(events[1].Metadata))
+
+			w.WriteHeader(http.StatusCreated)
+		})
+		s := httptest.NewServer(mux)
+		defer s.Close()
+
+		w := goro.NewWriter(goro.SlingerFunc(func() *sling.Sling {
+			return sling.New().Base(s.URL).Client(s.Client())
+		}), "test")
+
+		ctx := goro.WithCausationID(goro.WithCorrelationID(context.Background(), "workflow"), "cause")
+		err := w.Write(ctx, goro.ExpectedVersionAny,
+			goro.CreateEvent("first", []byte("{}"), nil, 0),
+			goro.CreateEvent("second", []byte("{}"), []byte("{\"$correlationId\":\"other\",\"user\":\"me\"}"), 1),
+		)
+		assert.Nil(t, err)
+	})
+
+	t.Run("it should write the correlation and causation ids in the metadata of binary events", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+		client := s.Connect()
+
+		event := goro.CreateEvent("binary", []byte{0, 1, 2}, []byte("{\"user\":\"me\"}"), 0)
+		event.Binary = true
+		ctx := goro.WithCausationID(goro.WithCorrelationID(context.Background(), "workflow"), "cause")
+		assert.Nil(t, client.Writer("test").Write(ctx, goro.ExpectedVersionNone, event))
+
+		events, err := client.FowardsReader("test").Read(context.Background(), 0, 1)
+		assert.Nil(t, err)
+		assert.Len(t, events, 1)
+		assert.Equal(t, []byte{0, 1, 2}, []byte(events[0].Data))
+		assert.JSONEq(t, "{\"$correlationId\":\"workflow\",\"$causationId\":\"cause\",\"user\":\"me\"}", string(events[0].Metadata))
+	})
+
+	t.Run("it should continue the workflow of an event", func(t *testing.T) {
+		event := goro.CreateEvent("first", []byte("{}"), []byte("{\"$correlationId\":\"workflow\"}"), 0)
+		ctx := goro.EventContext(context.Background(), event)
+
+		correlationID, ok := goro.CorrelationID(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, "workflow", correlationID)
+
+		causationID, ok := goro.CausationID(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, event.ID.String(), causationID)
+	})
+
+	t.Run("it should start a workflow with an uncorrelated event", func(t *testing.T) {
+		event := goro.CreateEvent("first", []byte("{}"), nil, 0)
+		ctx := goro.EventContext(context.Background(), event)
+
+		correlationID, ok := goro.CorrelationID(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, event.ID.String(), correlationID)
+	})
+}
+
+func TestConsume(t *testing.T) {
+	t.Run("it should handle messages with their event context", func(t *testing.T) {
+		event := goro.CreateEvent("first", []byte("{}"), []byte("{\"$correlationId\":\"workflow\"}"), 0)
+		succeeded, failed := &acknowledger{}, &acknowledger{}
+
+		subscriber := subscriberFunc(func(ctx context.Context) <-chan goro.StreamMessage {
+			stream := make(chan goro.StreamMessage, 2)
+			stream <- goro.StreamMessage{Event: event, Acknowledger: succeeded}
+			stream <- goro.StreamMessage{Event: event, Acknowledger: failed}
+			close(stream)
+			return stream
+		})
+
+		handled := 0
+		err := goro.Consume(context.Background(), subscriber, func(ctx context.Context, message goro.StreamMessage) error {
+			handled++
+			correlationID, _ := goro.CorrelationID(ctx)
+			assert.Equal(t, "workflow", correlationID)
+
+			if handled == 2 {
+				return errors.New("failed")
+			}
+			return nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, handled)
+		assert.True(t, succeeded.acked)
+		assert.Equal(t, goro.Action(goro.ActionRetry), failed.nacked)
+	})
+
+	t.Run("it should stop on errors", func(t *testing.T) {
+		subscriber := subscriberFunc(func(ctx context.Context) <-chan goro.StreamMessage {
+			stream := make(chan goro.StreamMessage, 1)
+			stream <- goro.StreamMessage{Error: goro.ErrStreamNotFound}
+			close(stream)
+			return stream
+		})
+
+		err := goro.Consume(context.Background(), subscriber, func(ctx context.Context, message goro.StreamMessage) error {
+			return nil
+		})
+		assert.Equal(t, goro.ErrStreamNotFound, err)
+	})
+}
@@ -0,0 +1,33 @@
+package goro
+
+import (
+	"context"
+	"fmt"
+)
+
+const streamPath = "/streams/%s"
+
+// DeleteStream deletes a stream. A soft deleted stream reads as not found until Events are written to it
+// again, and its old Events are scavenged. A hard deleted stream can never be written to or read again.
+func DeleteStream(ctx context.Context, slinger Slinger, stream string, hard bool) error {
+	s := slinger.
+		Sling().
+		Delete(fmt.Sprintf(streamPath, stream))
+	if hard {
+		s = s.Set("ES-HardDelete", "true")
+	}
+
+	req, err := s.Request()
+	if err != nil {
+		return err
+	}
+
+	req = req.WithContext(ctx)
+
+	res, err := slinger.Sling().Do(req, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	return relevantError(res)
+}
@@ -0,0 +1,63 @@
+package goro_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vectorhacker/goro"
+)
+
+func TestDeleteStream(t *testing.T) {
+	t.Run("it should soft delete a stream", func(t *testing.T) {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodDelete, r.Method)
+			assert.Equal(t, "/streams/account-1", r.URL.Path)
+			assert.Empty(t, r.Header.Get("ES-HardDelete"))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer s.Close()
+		client := goro.Connect(s.URL, goro.WithHTTPClient(s.Client()))
+
+		assert.Nil(t, client.DeleteStream(context.Background(), "account-1", false))
+	})
+
+	t.Run("it should hard delete a stream", func(t *testing.T) {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "true", r.Header.Get("ES-HardDelete"))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer s.Close()
+		client := goro.Connect(s.URL, goro.WithHTTPClient(s.Client()))
+
+		assert.Nil(t, client.DeleteStream(context.Background(), "account-1", true))
+	})
+
+	t.Run("it should fail on deleted streams", func(t *testing.T) {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusGone)
+		}))
+		defer s.Close()
+		client := goro.Connect(s.URL, goro.WithHTTPClient(s.Client()))
+
+		assert.True(t, errors.Is(client.DeleteStream(context.Background(), "account-1", true), goro.ErrStreamDeleted))
+	})
+}
+
+func TestReadStreamMetadata(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/streams/account-1/metadata", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{\"$maxCount\":1,\"$tb\":4}"))
+	}))
+	defer s.Close()
+	client := goro.Connect(s.URL, goro.WithHTTPClient(s.Client()))
+
+	metadata, err := client.StreamMetadata(context.Background(), "account-1")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), metadata.MaxCount)
+	assert.Equal(t, int64(4), *metadata.TruncateBefore)
+}
@@ -0,0 +1,337 @@
+package goro
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+const (
+	encryptionSubjectKey = "$goro.encryptionSubject"
+	encryptedFieldsKey   = "$goro.encryptedFields"
+)
+
+type encryptionRule struct {
+	subjectField string
+	fields       []string
+}
+
+// Encryptor encrypts selected fields of the json data of Events with a key per subject, such as a
+// customer, taken from a KeyStore. Deleting the key of a subject crypto-shreds its data: the encrypted
+// fields of its Events can no longer be read, without rewriting any stream.
+//
+// The subject and the encrypted fields are recorded in the Metadata of the Events, so Events can be
+// decrypted without knowing how they were encrypted.
+type Encryptor struct {
+	mu    sync.RWMutex
+	keys  KeyStore
+	rules map[string]encryptionRule
+}
+
+// NewEncryptor creates an Encryptor that takes its keys from a KeyStore
+func NewEncryptor(keys KeyStore) *Encryptor {
+	return &Encryptor{
+		keys:  keys,
+		rules: map[string]encryptionRule{},
+	}
+}
+
+// Register encrypts fields of the data of an Event type with the key of the subject found in subjectField,
+// which itself stays readable.
+func (e *Encryptor) Register(eventType, subjectField string, fields ...string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.rules[eventType] = encryptionRule{
+		subjectField: subjectField,
+		fields:       fields,
+	}
+}
+
+// Encrypt returns a copy of an Event with the fields registered for its type encrypted. Events of other
+// types, Events without any of the fields and Binary Events are returned as is.
+func (e *Encryptor) Encrypt(event Event) (Event, error) {
+	e.mu.RLock()
+	rule, ok := e.rules[event.Type]
+	e.mu.RUnlock()
+	if !ok || event.Binary {
+		return event, nil
+	}
+
+	data := map[string]json.RawMessage{}
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		return Event{}, err
+	}
+
+	subject, encrypted, err := encryptFields(e.keys, rule, data)
+	if err != nil || len(encrypted) == 0 {
+		return event, err
+	}
+
+	event.Data, err = json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+
+	event.Metadata, err = setMetadataValue(event.Metadata, encryptionSubjectKey, subject)
+	if err != nil {
+		return Event{}, err
+	}
+
+	event.Metadata, err = setMetadataValue(event.Metadata, encryptedFieldsKey, encrypted)
+	if err != nil {
+		return Event{}, err
+	}
+
+	return event, nil
+}
+
+// Decrypt returns a copy of an Event with its encrypted fields decrypted. When the key of the subject has
+// been deleted, the encrypted fields are set to null instead. Events that weren't encrypted are returned
+// as is.
+func (e *Encryptor) Decrypt(event Event) (Event, error) {
+	encrypted := []string{}
+	found, err := metadataValue(event.Metadata, encryptedFieldsKey, &encrypted)
+	if err != nil || !found {
+		return event, err
+	}
+
+	subject := ""
+	if _, err := metadataValue(event.Metadata, encryptionSubjectKey, &subject); err != nil {
+		return Event{}, err
+	}
+
+	data := map[string]json.RawMessage{}
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		return Event{}, err
+	}
+
+	if err := decryptFields(e.keys, subject, encrypted, data); err != nil {
+		return Event{}, err
+	}
+
+	event.Data, err = json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+
+	event.Metadata, err = deleteMetadataValues(event.Metadata, encryptionSubjectKey, encryptedFieldsKey)
+	if err != nil {
+		return Event{}, err
+	}
+
+	return event, nil
+}
+
+type encryptingCodec struct {
+	codec Codec
+	keys  KeyStore
+	rule  encryptionRule
+}
+
+// NewEncryptingCodec creates a Codec that encrypts fields of the json objects encoded by another Codec
+// with the key of the subject found in subjectField, to be used by an EventRegistry. Codecs don't see the
+// Metadata of Events, so the encrypted fields are recorded in the data itself. Deleting the key of a
+// subject decodes its encrypted fields as zero values.
+func NewEncryptingCodec(codec Codec, keys KeyStore, subjectField string, fields ...string) Codec {
+	return &encryptingCodec{
+		codec: codec,
+		keys:  keys,
+		rule: encryptionRule{
+			subjectField: subjectField,
+			fields:       fields,
+		},
+	}
+}
+
+// ContentType implements the Codec interface
+func (c *encryptingCodec) ContentType() string {
+	return c.codec.ContentType()
+}
+
+// Marshal implements the Codec interface
+func (c *encryptingCodec) Marshal(v interface{}) ([]byte, error) {
+	b, err := c.codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &data); err != nil {
+		return nil, err
+	}
+
+	_, encrypted, err := encryptFields(c.keys, c.rule, data)
+	if err != nil || len(encrypted) == 0 {
+		return b, err
+	}
+
+	data[encryptedFieldsKey], err = json.Marshal(encrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(data)
+}
+
+// Unmarshal implements the Codec interface
+func (c *encryptingCodec) Unmarshal(b []byte, v interface{}) error {
+	data := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &data); err != nil {
+		return err
+	}
+
+	raw, ok := data[encryptedFieldsKey]
+	if !ok {
+		return c.codec.Unmarshal(b, v)
+	}
+
+	encrypted := []string{}
+	if err := json.Unmarshal(raw, &encrypted); err != nil {
+		return err
+	}
+
+	subject := ""
+	if err := json.Unmarshal(data[c.rule.subjectField], &subject); err != nil {
+		return ErrNoEncryptionSubject
+	}
+
+	if err := decryptFields(c.keys, subject, encrypted, data); err != nil {
+		return err
+	}
+	delete(data, encryptedFieldsKey)
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return c.codec.Unmarshal(b, v)
+}
+
+// encryptFields encrypts the fields of a rule found in data in place, with the key of the subject found in
+// its subject field, and returns the subject and the fields it encrypted
+func encryptFields(keys KeyStore, rule encryptionRule, data map[string]json.RawMessage) (string, []string, error) {
+	fields := []string{}
+	for _, field := range rule.fields {
+		if _, ok := data[field]; ok {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return "", nil, nil
+	}
+
+	subject := ""
+	if raw, ok := data[rule.subjectField]; !ok || json.Unmarshal(raw, &subject) != nil || subject == "" {
+		return "", nil, ErrNoEncryptionSubject
+	}
+
+	key, err := keys.CreateKey(subject)
+	if err != nil {
+		return "", nil, err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", nil, err
+	}
+
+	for _, field := range fields {
+		nonce := make([]byte, gcm.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", nil, err
+		}
+
+		sealed := gcm.Seal(nonce, nonce, data[field], fieldAAD(subject, field))
+		data[field], err = json.Marshal(base64.StdEncoding.EncodeToString(sealed))
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	return subject, fields, nil
+}
+
+// decryptFields decrypts the encrypted fields of data in place, or sets them to null when the key of the
+// subject has been deleted
+func decryptFields(keys KeyStore, subject string, fields []string, data map[string]json.RawMessage) error {
+	key, err := keys.Key(subject)
+	if err != nil && err != ErrKeyNotFound {
+		return err
+	}
+
+	var gcm cipher.AEAD
+	if key != nil {
+		gcm, err = newGCM(key)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, field := range fields {
+		if gcm == nil {
+			data[field] = json.RawMessage("null")
+			continue
+		}
+
+		data[field], err = decryptField(gcm, data[field], fieldAAD(subject, field))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fieldAAD binds an encrypted value to its subject and field, so it can't be moved to another field or
+// Event without failing to decrypt
+func fieldAAD(subject, field string) []byte {
+	return []byte(subject + "/" + field)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func decryptField(gcm cipher.AEAD, value json.RawMessage, aad []byte) (json.RawMessage, error) {
+	encoded := ""
+	if err := json.Unmarshal(value, &encoded); err != nil {
+		return nil, err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("encrypted field is too short")
+	}
+
+	return gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], aad)
+}
+
+// NewEncryptingWriter creates a Writer that encrypts Events before writing them with another Writer
+func NewEncryptingWriter(encryptor *Encryptor, writer Writer) Writer {
+	return NewMappingWriter(encryptor.Encrypt, writer)
+}
+
+// NewDecryptingReader creates a Reader that decrypts the Events read by another Reader
+func NewDecryptingReader(encryptor *Encryptor, reader Reader) Reader {
+	return NewMappingReader(encryptor.Decrypt, reader)
+}
+
+// NewDecryptingSubscriber creates a Subscriber that decrypts the Events of another Subscriber
+func NewDecryptingSubscriber(encryptor *Encryptor, subscriber Subscriber) Subscriber {
+	return NewMappingSubscriber(encryptor.Decrypt, subscriber)
+}
@@ -0,0 +1,168 @@
+package goro_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vectorhacker/goro"
+)
+
+func TestEncryptor(t *testing.T) {
+	newEncryptor := func(keys goro.KeyStore) *goro.Encryptor {
+		encryptor := goro.NewEncryptor(keys)
+		encryptor.Register("registered", "customerId", "email", "address")
+		return encryptor
+	}
+	data := []byte("{\"customerId\":\"42\",\"email\":\"me@example.com\",\"address\":{\"city\":\"San Juan\"},\"plan\":\"gold\"}")
+
+	t.Run("it should encrypt and decrypt selected fields", func(t *testing.T) {
+		encryptor := newEncryptor(goro.NewMemoryKeyStore())
+		event := goro.CreateEvent("registered", data, nil, 0)
+
+		encrypted, err := encryptor.Encrypt(event)
+		assert.Nil(t, err)
+		assert.NotContains(t, string(encrypted.Data), "me@example.com")
+		assert.NotContains(t, string(encrypted.Data), "San Juan")
+		assert.Contains(t, string(encrypted.Data), "gold")
+		assert.JSONEq(t, "{\"$goro.encryptionSubject\":\"42\",\"$goro.encryptedFields\":[\"email\",\"address\"]}", string(encrypted.Metadata))
+
+		decrypted, err := encryptor.Decrypt(encrypted)
+		assert.Nil(t, err)
+		assert.JSONEq(t, string(data), string(decrypted.Data))
+		assert.Nil(t, decrypted.Metadata)
+	})
+
+	t.Run("it should shred the data of a deleted subject", func(t *testing.T) {
+		keys := goro.NewMemoryKeyStore()
+		encryptor := newEncryptor(keys)
+
+		encrypted, err := encryptor.Encrypt(goro.CreateEvent("registered", data, nil, 0))
+		assert.Nil(t, err)
+
+		assert.Nil(t, keys.DeleteKey("42"))
+
+		decrypted, err := encryptor.Decrypt(encrypted)
+		assert.Nil(t, err)
+		assert.JSONEq(t, "{\"customerId\":\"42\",\"email\":null,\"address\":null,\"plan\":\"gold\"}", string(decrypted.Data))
+	})
+
+	t.Run("it should not decrypt fields moved to another field", func(t *testing.T) {
+		encryptor := newEncryptor(goro.NewMemoryKeyStore())
+		encrypted, err := encryptor.Encrypt(goro.CreateEvent("registered", data, nil, 0))
+		assert.Nil(t, err)
+
+		fields := map[string]json.RawMessage{}
+		assert.Nil(t, json.Unmarshal(encrypted.Data, &fields))
+		fields["email"], fields["address"] = fields["address"], fields["email"]
+		encrypted.Data, err = json.Marshal(fields)
+		assert.Nil(t, err)
+
+		_, err = encryptor.Decrypt(encrypted)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("it should require a subject", func(t *testing.T) {
+		encryptor := newEncryptor(goro.NewMemoryKeyStore())
+
+		_, err := encryptor.Encrypt(goro.CreateEvent("registered", []byte("{\"email\":\"me@example.com\"}"), nil, 0))
+		assert.Equal(t, goro.ErrNoEncryptionSubject, err)
+	})
+
+	t.Run("it should leave other events alone", func(t *testing.T) {
+		encryptor := newEncryptor(goro.NewMemoryKeyStore())
+		event := goro.CreateEvent("other", data, nil, 0)
+
+		encrypted, err := encryptor.Encrypt(event)
+		assert.Nil(t, err)
+		assert.Equal(t, event, encrypted)
+
+		decrypted, err := encryptor.Decrypt(event)
+		assert.Nil(t, err)
+		assert.Equal(t, event, decrypted)
+	})
+
+	t.Run("it should decrypt the events of a Reader", func(t *testing.T) {
+		encryptor := newEncryptor(goro.NewMemoryKeyStore())
+		encrypted, err := encryptor.Encrypt(goro.CreateEvent("registered", data, nil, 0))
+		assert.Nil(t, err)
+
+		reader := goro.NewDecryptingReader(encryptor, readerFunc(func(ctx context.Context, start int64, count int) (goro.Events, error) {
+			return goro.Events{encrypted}, nil
+		}))
+
+		events, err := reader.Read(context.Background(), 0, 1)
+		assert.Nil(t, err)
+		assert.JSONEq(t, string(data), string(events[0].Data))
+	})
+}
+
+func TestEncryptingCodec(t *testing.T) {
+	type registered struct {
+		CustomerID string `json:"customerId"`
+		Email      string `json:"email"`
+		Plan       string `json:"plan"`
+	}
+	type upgraded struct {
+		Plan string `json:"plan"`
+	}
+
+	keys := goro.NewMemoryKeyStore()
+	registry := goro.NewEventRegistry(goro.WithCodec(goro.NewEncryptingCodec(goro.JSONCodec{}, keys, "customerId", "email")))
+	registry.Register("registered", registered{})
+	registry.Register("upgraded", upgraded{})
+
+	t.Run("it should encrypt and decrypt selected fields", func(t *testing.T) {
+		event, err := registry.Encode(registered{CustomerID: "42", Email: "me@example.com", Plan: "gold"})
+		assert.Nil(t, err)
+		assert.False(t, event.Binary)
+		assert.NotContains(t, string(event.Data), "me@example.com")
+		assert.Contains(t, string(event.Data), "gold")
+
+		v, err := registry.Decode(event)
+		assert.Nil(t, err)
+		assert.Equal(t, registered{CustomerID: "42", Email: "me@example.com", Plan: "gold"}, v)
+	})
+
+	t.Run("it should leave values without the fields alone", func(t *testing.T) {
+		event, err := registry.Encode(upgraded{Plan: "gold"})
+		assert.Nil(t, err)
+		assert.JSONEq(t, "{\"plan\":\"gold\"}", string(event.Data))
+	})
+
+	t.Run("it should shred the data of a deleted subject", func(t *testing.T) {
+		event, err := registry.Encode(registered{CustomerID: "43", Email: "you@example.com", Plan: "gold"})
+		assert.Nil(t, err)
+
+		assert.Nil(t, keys.DeleteKey("43"))
+
+		v, err := registry.Decode(event)
+		assert.Nil(t, err)
+		assert.Equal(t, registered{CustomerID: "43", Plan: "gold"}, v)
+	})
+}
+
+func TestFileKeyStore(t *testing.T) {
+	keys, err := goro.NewFileKeyStore(t.TempDir())
+	assert.Nil(t, err)
+
+	_, err = keys.Key("42")
+	assert.Equal(t, goro.ErrKeyNotFound, err)
+
+	key, err := keys.CreateKey("42")
+	assert.Nil(t, err)
+	assert.Len(t, key, 32)
+
+	same, err := keys.CreateKey("42")
+	assert.Nil(t, err)
+	assert.Equal(t, key, same)
+
+	found, err := keys.Key("42")
+	assert.Nil(t, err)
+	assert.Equal(t, key, found)
+
+	assert.Nil(t, keys.DeleteKey("42"))
+	_, err = keys.Key("42")
+	assert.Equal(t, goro.ErrKeyNotFound, err)
+}
@@ -1,12 +1,75 @@
 package goro
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
 
 // errors
 var (
-	ErrStreamNeverCreated = errors.New("stream never created")
-	ErrInvalidContentType = errors.New("invalid content type")
-	ErrStreamNotFound     = errors.New("the stream was not found")
-	ErrUnauthorized       = errors.New("no access")
-	ErrInternalError      = errors.New("internall error has occurred")
+	ErrStreamNeverCreated   = errors.New("stream never created")
+	ErrInvalidContentType   = errors.New("invalid content type")
+	ErrStreamNotFound       = errors.New("the stream was not found")
+	ErrUnauthorized         = errors.New("no access")
+	ErrInternalError        = errors.New("internall error has occurred")
+	ErrWrongExpectedVersion = errors.New("wrong expected version")
+	ErrUnknownEventType     = errors.New("unknown event type")
+	ErrNotProtoMessage      = errors.New("value is not a proto.Message")
+	ErrKeyNotFound          = errors.New("encryption key not found")
+	ErrNoEncryptionSubject  = errors.New("the data has no encryption subject")
+	ErrUnknownCompression   = errors.New("unknown compression")
+	ErrStreamDeleted        = errors.New("the stream was deleted")
+	ErrInvalidOption        = errors.New("invalid client option")
+	ErrNoLeader             = errors.New("no leader was found in the cluster")
+	ErrNotLeader            = errors.New("the request reached a node that is not the leader")
+	ErrImportConflict       = errors.New("the stream has events that are not in the import")
+	ErrForbidden            = errors.New("access denied")
+	ErrConflict             = errors.New("the resource already exists")
+	ErrPreconditionFailed   = errors.New("precondition failed")
+	ErrUnavailable          = errors.New("the server is unavailable")
+	ErrTimeout              = errors.New("the server timed out")
+	ErrCircuitOpen          = errors.New("the circuit breaker is open")
 )
+
+// HTTPError is returned when Event Store replies with a status that isn't successful. It wraps the error
+// matching the status, if there is one, so that errors.Is(err, ErrStreamNotFound) works.
+type HTTPError struct {
+	StatusCode int
+	Method     string
+	Path       string
+	// Message is the reason Event Store gave for the status
+	Message string
+	Err     error
+}
+
+func newHTTPError(res *http.Response, err error) *HTTPError {
+	e := &HTTPError{
+		StatusCode: res.StatusCode,
+		Message:    strings.TrimSpace(strings.TrimPrefix(res.Status, strconv.Itoa(res.StatusCode))),
+		Err:        err,
+	}
+	if res.Request != nil {
+		e.Method = res.Request.Method
+		e.Path = res.Request.URL.Path
+	}
+
+	return e
+}
+
+// Error implements the error interface
+func (e *HTTPError) Error() string {
+	message := fmt.Sprintf("%s %s: %d %s", e.Method, e.Path, e.StatusCode, e.Message)
+	if e.Err != nil {
+		message += ": " + e.Err.Error()
+	}
+
+	return message
+}
+
+// Unwrap returns the error matching the status
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
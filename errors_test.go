@@ -0,0 +1,87 @@
+package goro_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vectorhacker/goro"
+)
+
+func TestHTTPError(t *testing.T) {
+	ctx := context.Background()
+	statuses := map[int]error{
+		http.StatusBadRequest:          goro.ErrInvalidContentType,
+		http.StatusUnauthorized:        goro.ErrUnauthorized,
+		http.StatusForbidden:           goro.ErrForbidden,
+		http.StatusNotFound:            goro.ErrStreamNotFound,
+		http.StatusRequestTimeout:      goro.ErrTimeout,
+		http.StatusConflict:            goro.ErrConflict,
+		http.StatusGone:                goro.ErrStreamDeleted,
+		http.StatusPreconditionFailed:  goro.ErrPreconditionFailed,
+		http.StatusInternalServerError: goro.ErrInternalError,
+		http.StatusServiceUnavailable:  goro.ErrUnavailable,
+		http.StatusGatewayTimeout:      goro.ErrTimeout,
+		http.StatusTeapot:              nil,
+	}
+
+	for status, expected := range statuses {
+		t.Run("it should return an error for "+http.StatusText(status), func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(status)
+			}))
+			defer s.Close()
+			client := goro.Connect(s.URL, goro.WithHTTPClient(s.Client()))
+
+			_, err := client.FowardsReader("tests").Read(ctx, 0, 1)
+
+			httpErr := &goro.HTTPError{}
+			assert.True(t, errors.As(err, &httpErr))
+			assert.Equal(t, status, httpErr.StatusCode)
+			assert.Equal(t, http.MethodGet, httpErr.Method)
+			assert.Equal(t, "/streams/tests/0/forward/1", httpErr.Path)
+			assert.Equal(t, http.StatusText(status), httpErr.Message)
+			if expected != nil {
+				assert.True(t, errors.Is(err, expected))
+			}
+		})
+	}
+
+	t.Run("it should fail writes", func(t *testing.T) {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer s.Close()
+		client := goro.Connect(s.URL, goro.WithHTTPClient(s.Client()))
+
+		err := client.Writer("tests").Write(ctx, goro.ExpectedVersionAny, goro.CreateEvent("test", []byte("{}"), nil, 0))
+		assert.True(t, errors.Is(err, goro.ErrUnavailable))
+		assert.EqualError(t, err, "POST /streams/tests: 503 Service Unavailable: the server is unavailable")
+	})
+
+	t.Run("it should fail to create persistent subscriptions that already exist when asked to", func(t *testing.T) {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusConflict)
+		}))
+		defer s.Close()
+		client := goro.Connect(s.URL, goro.WithHTTPClient(s.Client()))
+
+		_, err := client.PersistentSubscriptionContext(ctx, "tests", "group", goro.PersistentSubscriptionSettings{}, goro.FailIfExists())
+		assert.True(t, errors.Is(err, goro.ErrConflict), "%v", err)
+	})
+
+	t.Run("it should join persistent subscriptions that already exist", func(t *testing.T) {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusConflict)
+		}))
+		defer s.Close()
+		client := goro.Connect(s.URL, goro.WithHTTPClient(s.Client()))
+
+		subscription, err := client.PersistentSubscription("tests", "group", goro.PersistentSubscriptionSettings{})
+		assert.Nil(t, err)
+		assert.NotNil(t, subscription)
+	})
+}
@@ -0,0 +1,602 @@
+// Package estest serves the Event Store HTTP API from memory, for integration tests. A Server covers the
+// parts of the API goro uses: writing and reading streams as atom feeds, long polling, stream metadata,
+// competing consumers with ack and nack, and the ping, info and stats of the node.
+package estest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/vectorhacker/goro"
+	"github.com/vectorhacker/goro/memory"
+)
+
+const (
+	eventsContentType = "application/vnd.eventstore.events+json"
+	jsonContentType   = "application/json"
+	binaryContentType = "application/octet-stream"
+)
+
+// Version is the version of Event Store a Server reports
+const Version = "20.10.0.0"
+
+// Server is an Event Store HTTP API served from memory over an httptest.Server
+type Server struct {
+	*httptest.Server
+	Handler *Handler
+}
+
+// NewServer starts a Server backed by an empty memory.Store
+func NewServer() *Server {
+	handler := NewHandler(memory.NewStore())
+	return &Server{
+		Server:  httptest.NewServer(handler),
+		Handler: handler,
+	}
+}
+
+// Connect creates a goro.Client for the Server
+func (s *Server) Connect(options ...goro.ClientOption) *goro.Client {
+	return goro.Connect(s.URL, append([]goro.ClientOption{goro.WithHTTPClient(s.Client())}, options...)...)
+}
+
+// Close stops the competing consumers of the Server and shuts it down
+func (s *Server) Close() {
+	s.Handler.Close()
+	s.Server.Close()
+}
+
+// group is a persistent subscription with the messages handed out to consumers that are waiting for an
+// ack or a nack
+type group struct {
+	messages <-chan goro.StreamMessage
+	mu       sync.Mutex
+	pending  map[uuid.UUID]goro.Acknowledger
+}
+
+// Handler serves the Event Store HTTP API from a memory.Store
+type Handler struct {
+	Store *memory.Store
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	mu     sync.Mutex
+	groups map[string]*group
+}
+
+// NewHandler creates a Handler for a memory.Store
+func NewHandler(store *memory.Store) *Handler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Handler{
+		Store:  store,
+		ctx:    ctx,
+		cancel: cancel,
+		groups: map[string]*group{},
+	}
+}
+
+// Close stops the competing consumers of the Handler
+func (h *Handler) Close() {
+	h.cancel()
+}
+
+// ServeHTTP implements the http.Handler interface
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) == 1 && r.Method == http.MethodGet {
+		h.node(w, r, parts[0])
+		return
+	}
+	if len(parts) < 2 {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch {
+	case parts[0] == "streams" && len(parts) == 2 && r.Method == http.MethodPost:
+		h.write(w, r, parts[1])
+	case parts[0] == "streams" && len(parts) == 2 && r.Method == http.MethodDelete:
+		h.deleteStream(w, r, parts[1])
+	case parts[0] == "streams" && len(parts) == 3 && parts[2] == "metadata" && r.Method == http.MethodGet:
+		h.readMetadata(w, r, parts[1])
+	case parts[0] == "streams" && len(parts) == 3 && parts[2] == "metadata" && r.Method == http.MethodPost:
+		h.writeMetadata(w, r, parts[1])
+	case parts[0] == "streams" && len(parts) == 3 && r.Method == http.MethodGet:
+		h.readEvent(w, r, parts[1], parts[2])
+	case parts[0] == "streams" && len(parts) == 5 && r.Method == http.MethodGet:
+		h.readFeed(w, r, parts[1], parts[2], parts[3], parts[4])
+	case parts[0] == "subscriptions" && len(parts) == 3 && r.Method == http.MethodPut:
+		h.createSubscription(w, r, parts[1], parts[2])
+	case parts[0] == "subscriptions" && len(parts) == 3 && r.Method == http.MethodPost:
+		h.updateSubscription(w, r, parts[1], parts[2])
+	case parts[0] == "subscriptions" && len(parts) == 4 && r.Method == http.MethodGet:
+		h.readSubscription(w, r, parts[1], parts[2], parts[3])
+	case parts[0] == "subscriptions" && len(parts) == 5 && r.Method == http.MethodPost:
+		h.acknowledge(w, r, parts[1], parts[2], parts[3], parts[4])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// node serves the ping, info and stats of a single leader node, which has idle queues
+func (h *Handler) node(w http.ResponseWriter, r *http.Request, resource string) {
+	var body interface{}
+	switch resource {
+	case "ping":
+		body = map[string]string{"text": "Ping request successfully handled"}
+	case "info":
+		body = map[string]interface{}{
+			"esVersion":   Version,
+			"state":       "leader",
+			"projections": map[string]string{"mode": "None"},
+			"features": map[string]bool{
+				"projections":    false,
+				"userManagement": false,
+				"atomPub":        true,
+			},
+		}
+	case "stats":
+		body = map[string]interface{}{
+			"proc": map[string]interface{}{},
+			"sys":  map[string]interface{}{},
+			"es": map[string]interface{}{
+				"queue": map[string]interface{}{
+					"MainQueue": map[string]interface{}{
+						"queueName": "MainQueue",
+						"length":    0,
+					},
+				},
+			},
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", jsonContentType)
+	json.NewEncoder(w).Encode(body)
+}
+
+// entry is an Event as it's embedded in an atom feed
+type entry struct {
+	Title               string          `json:"title"`
+	EventID             uuid.UUID       `json:"eventId"`
+	EventType           string          `json:"eventType"`
+	EventNumber         int64           `json:"eventNumber"`
+	Data                json.RawMessage `json:"data,omitempty"`
+	MetaData            json.RawMessage `json:"metaData,omitempty"`
+	StreamID            string          `json:"streamId"`
+	IsJSON              bool            `json:"isJson"`
+	IsMetaData          bool            `json:"isMetaData"`
+	PositionEventNumber int64           `json:"positionEventNumber"`
+	PositionStreamID    string          `json:"positionStreamId"`
+	Updated             time.Time       `json:"updated"`
+}
+
+func newEntry(event goro.Event) entry {
+	e := entry{
+		Title:               fmt.Sprintf("%d@%s", event.Version, event.Stream),
+		EventID:             event.ID,
+		EventType:           event.Type,
+		EventNumber:         event.Version,
+		MetaData:            event.Metadata,
+		StreamID:            event.Stream,
+		IsJSON:              !event.Binary,
+		IsMetaData:          len(event.Metadata) > 0,
+		PositionEventNumber: event.Position,
+		PositionStreamID:    event.PositionStream,
+		Updated:             event.At,
+	}
+
+	// binary data is served one event at a time, like in Event Store
+	if !event.Binary {
+		e.Data = event.Data
+	}
+
+	return e
+}
+
+// writeFeed writes events as the entries of an atom feed, newest first. Feeds of streams have an eTag,
+// which starts with the version of the last event of the stream like in Event Store.
+func writeFeed(w http.ResponseWriter, contentType, eTag string, events goro.Events) {
+	entries := make([]entry, len(events))
+	for i, event := range events {
+		entries[len(events)-1-i] = newEntry(event)
+	}
+
+	body := map[string]interface{}{
+		"entries": entries,
+	}
+	if eTag != "" {
+		body["eTag"] = eTag
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch err {
+	case goro.ErrStreamNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case goro.ErrStreamDeleted:
+		http.Error(w, err.Error(), http.StatusGone)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) currentVersion(ctx context.Context, stream string) int64 {
+	events, err := h.Store.BackwardsReader(stream).Read(ctx, goro.StreamHead, 1)
+	if err != nil || len(events) == 0 {
+		return goro.ExpectedVersionNone
+	}
+
+	return events[0].Version
+}
+
+func (h *Handler) write(w http.ResponseWriter, r *http.Request, stream string) {
+	expectedVersion := goro.ExpectedVersionAny
+	if header := r.Header.Get("ES-ExpectedVersion"); header != "" {
+		var err error
+		expectedVersion, err = strconv.ParseInt(header, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid ES-ExpectedVersion", http.StatusBadRequest)
+			return
+		}
+	}
+
+	events := goro.Events{}
+	contentType := r.Header.Get("Content-Type")
+	switch contentType {
+	case eventsContentType:
+		if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	case jsonContentType, binaryContentType:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		id, err := uuid.FromString(r.Header.Get("ES-EventId"))
+		eventType := r.Header.Get("ES-EventType")
+		if err != nil || eventType == "" {
+			http.Error(w, "missing ES-EventId or ES-EventType", http.StatusBadRequest)
+			return
+		}
+
+		var metadata json.RawMessage
+		if header := r.Header.Get("ES-EventMetadata"); header != "" {
+			if !json.Valid([]byte(header)) {
+				http.Error(w, "invalid ES-EventMetadata", http.StatusBadRequest)
+				return
+			}
+			metadata = json.RawMessage(header)
+		}
+
+		events = append(events, goro.Event{
+			ID:       id,
+			Type:     eventType,
+			Data:     data,
+			Metadata: metadata,
+			Binary:   contentType == binaryContentType || !json.Valid(data),
+		})
+	default:
+		http.Error(w, "unsupported content type", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	err := h.Store.Writer(stream).Write(r.Context(), expectedVersion, events...)
+	if err == goro.ErrWrongExpectedVersion {
+		w.Header().Set("ES-CurrentVersion", strconv.FormatInt(h.currentVersion(r.Context(), stream), 10))
+		http.Error(w, "Wrong expected EventNumber", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/streams/%s/%d", stream, h.currentVersion(r.Context(), stream)))
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *Handler) deleteStream(w http.ResponseWriter, r *http.Request, stream string) {
+	hard := r.Header.Get("ES-HardDelete") == "true"
+	if err := h.Store.DeleteStream(r.Context(), stream, hard); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) readEvent(w http.ResponseWriter, r *http.Request, stream, number string) {
+	reader := h.Store.FowardsReader(stream)
+	start, err := strconv.ParseInt(number, 10, 64)
+	if number == "head" {
+		reader, start, err = h.Store.BackwardsReader(stream), goro.StreamHead, nil
+	}
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	events, err := reader.Read(r.Context(), start, 1)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if len(events) == 0 || (number != "head" && events[0].Position != start) {
+		http.NotFound(w, r)
+		return
+	}
+
+	event := events[0]
+	if strings.Contains(r.Header.Get("Accept"), binaryContentType) {
+		w.Header().Set("Content-Type", binaryContentType)
+		w.Write(event.Data)
+		return
+	}
+
+	w.Header().Set("Content-Type", jsonContentType)
+	json.NewEncoder(w).Encode(newEntry(event))
+}
+
+func (h *Handler) readFeed(w http.ResponseWriter, r *http.Request, stream, start, direction, count string) {
+	n, err := strconv.Atoi(count)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	from := goro.StreamHead
+	if start != "head" {
+		from, err = strconv.ParseInt(start, 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+	}
+
+	var reader goro.Reader
+	switch direction {
+	case "forward":
+		reader = h.Store.FowardsReader(stream)
+	case "backward":
+		// Event Store pages include the event they start from, while Readers begin after it
+		switch from {
+		case goro.StreamHead:
+			reader = h.Store.BackwardsReader(stream)
+		case 0:
+			// only the first event is at or before it
+			reader = h.Store.FowardsReader(stream)
+			if n > 1 {
+				n = 1
+			}
+		default:
+			reader = h.Store.BackwardsReader(stream)
+			from--
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	events, err := reader.Read(r.Context(), from, n)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// long polling waits for new events when reading past the head of a stream
+	longPoll, _ := strconv.Atoi(r.Header.Get("ES-LongPoll"))
+	if len(events) == 0 && direction == "forward" && longPoll > 0 {
+		ctx, cancel := context.WithTimeout(r.Context(), time.Duration(longPoll)*time.Second)
+		defer cancel()
+		stop := context.AfterFunc(h.ctx, cancel)
+		defer stop()
+
+		if _, ok := <-h.Store.CatchupSubscription(stream, from).Subscribe(ctx); ok {
+			events, err = reader.Read(r.Context(), from, n)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+		}
+	}
+
+	eTag := fmt.Sprintf("%d;%s", h.currentVersion(r.Context(), stream), "estest")
+	writeFeed(w, "application/vnd.eventstore.atom+json", eTag, events)
+}
+
+func (h *Handler) readMetadata(w http.ResponseWriter, r *http.Request, stream string) {
+	metadata, err := h.Store.StreamMetadata(r.Context(), stream)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", jsonContentType)
+	json.NewEncoder(w).Encode(metadata)
+}
+
+func (h *Handler) writeMetadata(w http.ResponseWriter, r *http.Request, stream string) {
+	metadata := goro.StreamMetadata{}
+	switch r.Header.Get("Content-Type") {
+	case eventsContentType:
+		events := goro.Events{}
+		if err := json.NewDecoder(r.Body).Decode(&events); err != nil || len(events) != 1 {
+			http.Error(w, "expected a single metadata event", http.StatusBadRequest)
+			return
+		}
+		if err := json.Unmarshal(events[0].Data, &metadata); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	case jsonContentType:
+		if err := json.NewDecoder(r.Body).Decode(&metadata); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "unsupported content type", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	if err := h.Store.SetStreamMetadata(r.Context(), stream, metadata); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func groupKey(stream, subscriptionName string) string {
+	return stream + "::" + subscriptionName
+}
+
+func (h *Handler) group(stream, subscriptionName string) (*group, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	g, ok := h.groups[groupKey(stream, subscriptionName)]
+	return g, ok
+}
+
+func (h *Handler) createSubscription(w http.ResponseWriter, r *http.Request, stream, subscriptionName string) {
+	settings := goro.PersistentSubscriptionSettings{}
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil && err != io.EOF {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := groupKey(stream, subscriptionName)
+	if _, ok := h.groups[key]; ok {
+		http.Error(w, "subscription already exists", http.StatusConflict)
+		return
+	}
+
+	subscription, err := h.Store.PersistentSubscription(stream, subscriptionName, settings)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	h.groups[key] = &group{
+		messages: subscription.Subscribe(h.ctx),
+		pending:  map[uuid.UUID]goro.Acknowledger{},
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *Handler) updateSubscription(w http.ResponseWriter, r *http.Request, stream, subscriptionName string) {
+	if _, ok := h.group(stream, subscriptionName); !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) readSubscription(w http.ResponseWriter, r *http.Request, stream, subscriptionName, count string) {
+	g, ok := h.group(stream, subscriptionName)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	n, err := strconv.Atoi(count)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	var timeout <-chan time.Time
+	if longPoll, _ := strconv.Atoi(r.Header.Get("ES-LongPoll")); longPoll > 0 {
+		timeout = time.After(time.Duration(longPoll) * time.Second)
+	}
+
+	events := goro.Events{}
+	for len(events) < n {
+		var message goro.StreamMessage
+		if len(events) == 0 && timeout != nil {
+			select {
+			case message = <-g.messages:
+			case <-timeout:
+			case <-r.Context().Done():
+			case <-h.ctx.Done():
+			}
+		} else {
+			select {
+			case message = <-g.messages:
+			default:
+			}
+		}
+		if message.Acknowledger == nil {
+			break
+		}
+
+		g.mu.Lock()
+		g.pending[message.Event.ID] = message.Acknowledger
+		g.mu.Unlock()
+		events = append(events, message.Event)
+	}
+
+	writeFeed(w, "application/vnd.eventstore.competingatom+json", "", events)
+}
+
+func (h *Handler) acknowledge(w http.ResponseWriter, r *http.Request, stream, subscriptionName, verb, messageID string) {
+	g, ok := h.group(stream, subscriptionName)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	id, err := uuid.FromString(messageID)
+	if err != nil || (verb != "ack" && verb != "nack") {
+		http.NotFound(w, r)
+		return
+	}
+
+	g.mu.Lock()
+	acknowledger, ok := g.pending[id]
+	delete(g.pending, id)
+	g.mu.Unlock()
+	if !ok {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	switch verb {
+	case "ack":
+		err = acknowledger.Ack()
+	case "nack":
+		action := goro.Action(r.URL.Query().Get("action"))
+		if action == "" {
+			action = goro.ActionRetry
+		}
+		err = acknowledger.Nack(action)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,207 @@
+package estest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vectorhacker/goro"
+	"github.com/vectorhacker/goro/estest"
+)
+
+func generateEvents(count int) goro.Events {
+	events := make(goro.Events, count)
+	for i := range events {
+		events[i] = goro.CreateEvent("deposit", []byte("{\"double\":\"trouble\"}"), nil, int64(i))
+	}
+
+	return events
+}
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("it should write and read streams", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+		client := s.Connect()
+
+		err := client.Writer("account-1").Write(ctx, goro.ExpectedVersionNone, generateEvents(15)...)
+		assert.Nil(t, err)
+
+		events, err := client.FowardsReader("account-1").Read(ctx, 0, 15)
+		assert.Nil(t, err)
+		assert.Len(t, events, 15)
+		for i, event := range events {
+			assert.Equal(t, int64(i), event.Version)
+		}
+
+		events, err = client.BackwardsReader("account-1").Read(ctx, goro.StreamHead, 3)
+		assert.Nil(t, err)
+		assert.Len(t, events, 3)
+		assert.Equal(t, int64(14), events[0].Version)
+		assert.Equal(t, int64(12), events[2].Version)
+
+		for _, start := range []int64{0, 5, 13} {
+			events, err = client.BackwardsReader("account-1").Read(ctx, start, 15)
+			assert.Nil(t, err)
+			stored, err := s.Handler.Store.BackwardsReader("account-1").Read(ctx, start, 15)
+			assert.Nil(t, err)
+			assert.Len(t, events, int(start)+2)
+			assert.Equal(t, len(stored), len(events))
+			for i := range stored {
+				assert.Equal(t, stored[i].Version, events[i].Version)
+			}
+		}
+
+		_, err = client.FowardsReader("account-2").Read(ctx, 0, 1)
+		assert.True(t, errors.Is(err, goro.ErrStreamNotFound), "%v", err)
+	})
+
+	t.Run("it should enforce expected versions", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+		client := s.Connect()
+
+		assert.Nil(t, client.Writer("account-1").Write(ctx, goro.ExpectedVersionNone, generateEvents(1)...))
+		err := client.Writer("account-1").Write(ctx, goro.ExpectedVersionNone, generateEvents(1)...)
+		assert.True(t, errors.Is(err, goro.ErrWrongExpectedVersion), "%v", err)
+	})
+
+	t.Run("it should write and read binary events", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+		client := s.Connect()
+
+		event := goro.CreateEvent("binary", []byte{0xff, 0x00, 0x01}, []byte("{\"user\":\"me\"}"), 0)
+		event.Binary = true
+		assert.Nil(t, client.Writer("blobs").Write(ctx, goro.ExpectedVersionAny, event))
+
+		events, err := client.FowardsReader("blobs").Read(ctx, 0, 1)
+		assert.Nil(t, err)
+		assert.Len(t, events, 1)
+		assert.True(t, events[0].Binary)
+		assert.Equal(t, []byte{0xff, 0x00, 0x01}, []byte(events[0].Data))
+		assert.JSONEq(t, "{\"user\":\"me\"}", string(events[0].Metadata))
+	})
+
+	t.Run("it should stream new events to catchup subscriptions", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+		client := s.Connect()
+
+		assert.Nil(t, client.Writer("account-1").Write(ctx, goro.ExpectedVersionAny, generateEvents(2)...))
+
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		messages := client.CatchupSubscription("account-1", 0).Subscribe(ctx)
+
+		for i := 0; i < 2; i++ {
+			message := <-messages
+			assert.Nil(t, message.Error)
+			assert.Equal(t, int64(i), message.Event.Version)
+		}
+
+		assert.Nil(t, client.Writer("account-1").Write(ctx, goro.ExpectedVersionAny, generateEvents(1)...))
+		message := <-messages
+		assert.Nil(t, message.Error)
+		assert.Equal(t, int64(2), message.Event.Version)
+	})
+
+	t.Run("it should share events between competing consumers", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+		client := s.Connect()
+
+		assert.Nil(t, client.Writer("account-1").Write(ctx, goro.ExpectedVersionAny, generateEvents(3)...))
+
+		subscription, err := client.PersistentSubscription("account-1", "testing", goro.PersistentSubscriptionSettings{})
+		assert.Nil(t, err)
+
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		messages := subscription.Subscribe(ctx)
+
+		versions := []int64{}
+		for len(versions) < 3 {
+			message := <-messages
+			assert.Nil(t, message.Error)
+			versions = append(versions, message.Event.Version)
+
+			if message.Event.Version == 1 {
+				assert.Nil(t, message.Nack(goro.ActionPark))
+			} else {
+				assert.Nil(t, message.Ack())
+			}
+		}
+		assert.ElementsMatch(t, []int64{0, 1, 2}, versions)
+
+		parked := s.Handler.Store.Parked("account-1", "testing")
+		assert.Len(t, parked, 1)
+		assert.Equal(t, int64(1), parked[0].Version)
+	})
+
+	t.Run("it should keep snapshots with stream metadata", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+		client := s.Connect()
+
+		assert.Nil(t, client.SetStreamMetadata(ctx, "account-1-snapshot", goro.StreamMetadata{MaxCount: 1}))
+		assert.Nil(t, client.Writer("account-1-snapshot").Write(ctx, goro.ExpectedVersionAny, generateEvents(3)...))
+
+		events, err := client.BackwardsReader("account-1-snapshot").Read(ctx, goro.StreamHead, 10)
+		assert.Nil(t, err)
+		assert.Len(t, events, 1)
+		assert.Equal(t, int64(2), events[0].Version)
+	})
+
+	t.Run("it should read stream metadata", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+		client := s.Connect()
+
+		metadata, err := client.StreamMetadata(ctx, "account-1")
+		assert.Nil(t, err)
+		assert.Equal(t, goro.StreamMetadata{}, metadata)
+
+		assert.Nil(t, client.SetStreamMetadata(ctx, "account-1", goro.StreamMetadata{MaxCount: 5}))
+		metadata, err = client.StreamMetadata(ctx, "account-1")
+		assert.Nil(t, err)
+		assert.Equal(t, goro.StreamMetadata{MaxCount: 5}, metadata)
+	})
+
+	t.Run("it should delete streams", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+		client := s.Connect()
+
+		assert.Nil(t, client.Writer("account-1").Write(ctx, goro.ExpectedVersionNone, generateEvents(3)...))
+		assert.Nil(t, client.DeleteStream(ctx, "account-1", false))
+		_, err := client.FowardsReader("account-1").Read(ctx, 0, 10)
+		assert.True(t, errors.Is(err, goro.ErrStreamNotFound), "%v", err)
+
+		assert.Nil(t, client.DeleteStream(ctx, "account-1", true))
+		_, err = client.FowardsReader("account-1").Read(ctx, 0, 10)
+		assert.True(t, errors.Is(err, goro.ErrStreamDeleted), "%v", err)
+	})
+
+	t.Run("it should report the node", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+		client := s.Connect()
+
+		assert.Nil(t, client.Ping(ctx))
+
+		info, err := client.Info(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, estest.Version, info.Version)
+		assert.Equal(t, "leader", info.State)
+		assert.True(t, info.AtLeast(20, 10))
+
+		stats, err := client.Stats(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(0), stats.EventStore.Queues["MainQueue"].Length)
+	})
+}
@@ -0,0 +1,238 @@
+package goro
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	defaultExportPageSize  = 100
+	defaultImportChunkSize = 100
+)
+
+// exportedEvent is an Event as it's stored in a JSON Lines export. The data of Binary Events is stored as
+// a base64 string.
+type exportedEvent struct {
+	ID       uuid.UUID       `json:"eventId"`
+	Type     string          `json:"eventType"`
+	Version  int64           `json:"eventNumber"`
+	At       time.Time       `json:"created"`
+	Data     json.RawMessage `json:"data,omitempty"`
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+	Binary   bool            `json:"binary,omitempty"`
+}
+
+type exporter struct {
+	start    int64
+	pageSize int
+}
+
+// ExportOption applies options to an Export
+type ExportOption func(*exporter)
+
+// WithExportStart starts an Export at an event number, to resume an interrupted one
+func WithExportStart(start int64) ExportOption {
+	return func(e *exporter) {
+		e.start = start
+	}
+}
+
+// WithExportPageSize sets the number of Events read at a time by an Export
+func WithExportPageSize(pageSize int) ExportOption {
+	return func(e *exporter) {
+		e.pageSize = pageSize
+	}
+}
+
+// Export writes the Events of a stream to w as JSON Lines, one Event per line, preserving their ids,
+// types, data and metadata. The stream is read a page at a time, so it doesn't have to fit in memory.
+// An interrupted Export can be resumed by starting after the last line written, see LastExported.
+func Export(ctx context.Context, streams Streams, stream string, w io.Writer, options ...ExportOption) error {
+	e := &exporter{
+		pageSize: defaultExportPageSize,
+	}
+	for _, opt := range options {
+		opt(e)
+	}
+
+	reader := streams.FowardsReader(stream)
+	encoder := json.NewEncoder(w)
+	next := e.start
+	for {
+		events, err := reader.Read(ctx, next, e.pageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			exported := exportedEvent{
+				ID:       event.ID,
+				Type:     event.Type,
+				Version:  event.Version,
+				At:       event.At,
+				Data:     event.Data,
+				Metadata: event.Metadata,
+				Binary:   event.Binary,
+			}
+			if event.Binary {
+				exported.Data, err = json.Marshal([]byte(event.Data))
+				if err != nil {
+					return err
+				}
+			}
+
+			if err := encoder.Encode(exported); err != nil {
+				return err
+			}
+			next = position(event) + 1
+		}
+
+		if len(events) < e.pageSize {
+			return nil
+		}
+	}
+}
+
+// LastExported scans an export and returns the event number of its last complete line, or
+// ExpectedVersionNone if there is none, along with the size of the complete lines. To resume an
+// interrupted Export, truncate the export to that size and start at the next event number.
+func LastExported(r io.Reader) (int64, int64, error) {
+	last := ExpectedVersionNone
+	size := int64(0)
+
+	b := bufio.NewReader(r)
+	for {
+		line, err := b.ReadBytes('\n')
+		if err == io.EOF {
+			// a line without a newline was interrupted
+			return last, size, nil
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+
+		exported := exportedEvent{}
+		if err := json.Unmarshal(line, &exported); err != nil {
+			return 0, 0, err
+		}
+
+		last = exported.Version
+		size += int64(len(line))
+	}
+}
+
+type importer struct {
+	chunkSize int
+}
+
+// ImportOption applies options to an Import
+type ImportOption func(*importer)
+
+// WithImportChunkSize sets the number of Events written at a time by an Import
+func WithImportChunkSize(chunkSize int) ImportOption {
+	return func(i *importer) {
+		i.chunkSize = chunkSize
+	}
+}
+
+// Import writes the Events of a JSON Lines export to a stream, in chunks, preserving their ids, types,
+// data and metadata. Import expects to be the only writer of the stream, so that the nth line becomes
+// the nth Event of the stream; chunks are written with an expected version to enforce it. An
+// interrupted Import resumes where it stopped when it's run again with the same export, since the lines
+// already in the stream are skipped. ErrImportConflict is returned if the stream has Events that don't
+// come from the export.
+func Import(ctx context.Context, r io.Reader, streams Streams, stream string, options ...ImportOption) error {
+	i := &importer{
+		chunkSize: defaultImportChunkSize,
+	}
+	for _, opt := range options {
+		opt(i)
+	}
+
+	current := ExpectedVersionNone
+	var head Event
+	resumed := true
+	events, err := streams.BackwardsReader(stream).Read(ctx, StreamHead, 1)
+	if err != nil && !errors.Is(err, ErrStreamNotFound) {
+		return err
+	}
+	if len(events) > 0 {
+		head = events[0]
+		current = head.Version
+		resumed = false
+	}
+
+	writer := streams.Writer(stream)
+	chunk := Events{}
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+
+		if err := writer.Write(ctx, current, chunk...); err != nil {
+			return err
+		}
+		current += int64(len(chunk))
+		chunk = Events{}
+		return nil
+	}
+
+	decoder := json.NewDecoder(r)
+	for line := int64(0); ; line++ {
+		exported := exportedEvent{}
+		err := decoder.Decode(&exported)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		// skip the lines written by an interrupted Import
+		if line <= current {
+			if line == current {
+				if exported.ID != head.ID {
+					return ErrImportConflict
+				}
+				resumed = true
+			}
+			continue
+		}
+
+		event := Event{
+			ID:       exported.ID,
+			Type:     exported.Type,
+			Version:  line,
+			At:       exported.At,
+			Data:     exported.Data,
+			Metadata: exported.Metadata,
+			Binary:   exported.Binary,
+		}
+		if exported.Binary {
+			data := []byte{}
+			if err := json.Unmarshal(exported.Data, &data); err != nil {
+				return err
+			}
+			event.Data = data
+		}
+
+		chunk = append(chunk, event)
+		if len(chunk) >= i.chunkSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !resumed {
+		return ErrImportConflict
+	}
+
+	return flush()
+}
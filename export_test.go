@@ -0,0 +1,93 @@
+package goro_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vectorhacker/goro"
+	"github.com/vectorhacker/goro/memory"
+)
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	newSource := func() (*memory.Store, goro.Events) {
+		store := memory.NewStore()
+		events := goro.Events{}
+		for i := 0; i < 25; i++ {
+			events = append(events, goro.CreateEvent("deposit", []byte("{\"amount\":1}"), []byte("{\"by\":\"me\"}"), int64(i)))
+		}
+		binary := goro.CreateEvent("scan", []byte{0xde, 0xad, 0xbe, 0xef}, nil, 25)
+		binary.Binary = true
+		events = append(events, binary)
+
+		assert.Nil(t, store.Writer("account-1").Write(ctx, goro.ExpectedVersionNone, events[:25]...))
+		assert.Nil(t, store.Writer("account-1").Write(ctx, 24, binary))
+		return store, events
+	}
+
+	t.Run("it should export and import a stream", func(t *testing.T) {
+		source, expected := newSource()
+		b := new(bytes.Buffer)
+		assert.Nil(t, goro.Export(ctx, source, "account-1", b, goro.WithExportPageSize(10)))
+		assert.Equal(t, 26, strings.Count(b.String(), "\n"))
+
+		target := memory.NewStore()
+		assert.Nil(t, goro.Import(ctx, b, target, "account-2", goro.WithImportChunkSize(10)))
+
+		events, err := target.FowardsReader("account-2").Read(ctx, 0, 100)
+		assert.Nil(t, err)
+		assert.Len(t, events, 26)
+		for i, event := range events {
+			assert.Equal(t, expected[i].ID, event.ID)
+			assert.Equal(t, expected[i].Type, event.Type)
+			assert.Equal(t, []byte(expected[i].Data), []byte(event.Data))
+			assert.Equal(t, []byte(expected[i].Metadata), []byte(event.Metadata))
+			assert.Equal(t, expected[i].Binary, event.Binary)
+		}
+	})
+
+	t.Run("it should resume an interrupted export", func(t *testing.T) {
+		source, _ := newSource()
+		b := new(bytes.Buffer)
+		assert.Nil(t, goro.Export(ctx, source, "account-1", b))
+		complete := b.String()
+
+		// cut the export in the middle of a line
+		interrupted := complete[:strings.Index(complete, "\"eventNumber\":10")]
+		last, size, err := goro.LastExported(strings.NewReader(interrupted))
+		assert.Nil(t, err)
+		assert.Equal(t, int64(9), last)
+
+		resumed := bytes.NewBufferString(interrupted[:size])
+		assert.Nil(t, goro.Export(ctx, source, "account-1", resumed, goro.WithExportStart(last+1)))
+		assert.Equal(t, complete, resumed.String())
+	})
+
+	t.Run("it should resume an interrupted import", func(t *testing.T) {
+		source, _ := newSource()
+		b := new(bytes.Buffer)
+		assert.Nil(t, goro.Export(ctx, source, "account-1", b))
+		lines := strings.SplitAfter(b.String(), "\n")
+
+		target := memory.NewStore()
+		assert.Nil(t, goro.Import(ctx, strings.NewReader(strings.Join(lines[:12], "")), target, "account-2"))
+		assert.Nil(t, goro.Import(ctx, strings.NewReader(b.String()), target, "account-2"))
+
+		events, err := target.FowardsReader("account-2").Read(ctx, 0, 100)
+		assert.Nil(t, err)
+		assert.Len(t, events, 26)
+	})
+
+	t.Run("it should refuse to import into a different stream", func(t *testing.T) {
+		source, _ := newSource()
+		b := new(bytes.Buffer)
+		assert.Nil(t, goro.Export(ctx, source, "account-1", b))
+
+		target := memory.NewStore()
+		assert.Nil(t, target.Writer("account-2").Write(ctx, goro.ExpectedVersionNone, goro.CreateEvent("other", []byte("{}"), nil, 0)))
+		assert.Equal(t, goro.ErrImportConflict, goro.Import(ctx, b, target, "account-2"))
+	})
+}
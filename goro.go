@@ -20,7 +20,8 @@ type Author struct {
 }
 
 // Event represents an Event in Event Store
-// the data and Metadata must be json encoded
+// the data and Metadata must be json encoded, unless the Event is Binary. Binary Events hold raw bytes
+// in Data and are written one at a time, with their json Metadata.
 type Event struct {
 	At             time.Time       `json:"updated,omitempty"`
 	Author         Author          `json:"author,omitempty"`
@@ -32,13 +33,19 @@ type Event struct {
 	ID             uuid.UUID       `json:"eventID"`
 	Version        int64           `json:"eventNumber"`
 	Position       int64           `json:"positionEventNumber,omitempty"`
+	Binary         bool            `json:"-"`
+}
+
+// NewUUID generates a new random (version 4) uuid suitable for use as an Event ID
+func NewUUID() uuid.UUID {
+	return uuid.Must(uuid.NewV4())
 }
 
 // CreateEvent initializes a new Event with an event type, some data, metadata, and a version you
 // specify. It then creates a random uuid and sets the time it was created at.
 func CreateEvent(eventType string, data, metadata json.RawMessage, version int64) Event {
 	return Event{
-		ID:       uuid.Must(uuid.NewV4()),
+		ID:       NewUUID(),
 		Type:     eventType,
 		Data:     data,
 		Metadata: metadata,
@@ -65,9 +72,11 @@ func (e Events) Less(a, b int) bool {
 	return e[a].Version < e[b].Version
 }
 
-// StreamMessage contains an Event or an error
+// StreamMessage contains an Event or an error. Value holds the decoded data of the Event when the
+// message comes from a Subscriber created with NewDecodingSubscriber.
 type StreamMessage struct {
 	Event        Event
+	Value        interface{}
 	Acknowledger Acknowledger
 	Error        error
 }
@@ -142,14 +151,29 @@ func (f SlingerFunc) Sling() *sling.Sling {
 	return f()
 }
 
-func relevantError(statusCode int) error {
+// statusError returns the error matching an http status, or nil if there is none
+func statusError(statusCode int) error {
 	switch statusCode {
 	case http.StatusNotFound:
 		return ErrStreamNotFound
+	case http.StatusGone:
+		return ErrStreamDeleted
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return ErrNotLeader
 	case http.StatusUnauthorized:
 		return ErrUnauthorized
+	case http.StatusForbidden:
+		return ErrForbidden
+	case http.StatusConflict:
+		return ErrConflict
+	case http.StatusPreconditionFailed:
+		return ErrPreconditionFailed
 	case http.StatusInternalServerError:
 		return ErrInternalError
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return ErrUnavailable
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return ErrTimeout
 	case http.StatusBadRequest:
 		return ErrInvalidContentType
 	case http.StatusNotAcceptable:
@@ -158,3 +182,12 @@ func relevantError(statusCode int) error {
 		return nil
 	}
 }
+
+// relevantError returns an *HTTPError for responses that aren't successful
+func relevantError(res *http.Response) error {
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+
+	return newHTTPError(res, statusError(res.StatusCode))
+}
@@ -0,0 +1,165 @@
+// Package goroprom exports the metrics of a goro.Client to Prometheus. A Collector is both a
+// goro.MetricsCollector, to pass to goro.WithMetrics, and a prometheus.Collector, to register:
+//
+//	collector := goroprom.NewCollector()
+//	prometheus.MustRegister(collector)
+//	client, err := goro.NewClient("http://localhost:2113", goro.WithMetrics(collector))
+//
+// Streams are labeled by category, the part of their name before the first dash, so that the number of
+// series stays bounded however many streams there are. Subscription lag is labeled by the subscribed
+// stream instead, since there are few subscriptions and each has its own lag.
+package goroprom
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vectorhacker/goro"
+)
+
+const namespace = "goro"
+
+// Collector collects the metrics of goro Clients as Prometheus metrics
+type Collector struct {
+	requestDuration *prometheus.HistogramVec
+	eventsWritten   *prometheus.CounterVec
+	eventsRead      *prometheus.CounterVec
+	subscriptionLag *prometheus.GaugeVec
+	acknowledged    *prometheus.CounterVec
+	reconnects      prometheus.Counter
+	category        func(stream string) string
+}
+
+// CollectorOption applies options to a Collector
+type CollectorOption func(*Collector)
+
+// WithCategories sets how streams are mapped to the values of the category label. The mapping must
+// return few distinct values, since each one creates its own series.
+func WithCategories(category func(stream string) string) CollectorOption {
+	return func(c *Collector) {
+		c.category = category
+	}
+}
+
+// Category returns the category of a stream, which is the part of its name before the first dash. The
+// category and event type streams of Event Store, like $ce-account, are in the category they are about.
+func Category(stream string) string {
+	for _, prefix := range []string{"$ce-", "$et-"} {
+		stream = strings.TrimPrefix(stream, prefix)
+	}
+
+	if i := strings.Index(stream, "-"); i >= 0 {
+		return stream[:i]
+	}
+
+	return stream
+}
+
+var (
+	_ goro.MetricsCollector = (*Collector)(nil)
+	_ prometheus.Collector  = (*Collector)(nil)
+)
+
+// NewCollector creates a Collector. It has to be registered to be exported.
+func NewCollector(options ...CollectorOption) *Collector {
+	c := &Collector{
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_duration_seconds",
+			Help:      "How long the operations of the client took, by operation and result.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation", "result"}),
+		eventsWritten: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "events_written_total",
+			Help:      "The events written, by stream category.",
+		}, []string{"category"}),
+		eventsRead: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "events_read_total",
+			Help:      "The events read by readers and subscriptions, by stream category.",
+		}, []string{"category"}),
+		subscriptionLag: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "subscription_lag_events",
+			Help:      "How many events the catchup subscriptions of a stream are behind its head.",
+		}, []string{"stream"}),
+		acknowledged: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "acknowledgements_total",
+			Help:      "The events acked or nacked in persistent subscriptions, by action.",
+		}, []string{"category", "subscription", "action"}),
+		reconnects: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "reconnects_total",
+			Help:      "The requests sent to another node of the cluster because their node couldn't be reached.",
+		}),
+		category: Category,
+	}
+	for _, opt := range options {
+		opt(c)
+	}
+
+	return c
+}
+
+// Describe implements the prometheus.Collector interface
+func (c *Collector) Describe(descriptions chan<- *prometheus.Desc) {
+	c.requestDuration.Describe(descriptions)
+	c.eventsWritten.Describe(descriptions)
+	c.eventsRead.Describe(descriptions)
+	c.subscriptionLag.Describe(descriptions)
+	c.acknowledged.Describe(descriptions)
+	c.reconnects.Describe(descriptions)
+}
+
+// Collect implements the prometheus.Collector interface
+func (c *Collector) Collect(metrics chan<- prometheus.Metric) {
+	c.requestDuration.Collect(metrics)
+	c.eventsWritten.Collect(metrics)
+	c.eventsRead.Collect(metrics)
+	c.subscriptionLag.Collect(metrics)
+	c.acknowledged.Collect(metrics)
+	c.reconnects.Collect(metrics)
+}
+
+// ObserveRequest implements the goro.MetricsCollector interface
+func (c *Collector) ObserveRequest(operation string, duration time.Duration, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+
+	c.requestDuration.WithLabelValues(operation, result).Observe(duration.Seconds())
+}
+
+// EventsWritten implements the goro.MetricsCollector interface
+func (c *Collector) EventsWritten(stream string, count int) {
+	c.eventsWritten.WithLabelValues(c.category(stream)).Add(float64(count))
+}
+
+// EventsRead implements the goro.MetricsCollector interface
+func (c *Collector) EventsRead(stream string, count int) {
+	c.eventsRead.WithLabelValues(c.category(stream)).Add(float64(count))
+}
+
+// SubscriptionLag implements the goro.MetricsCollector interface
+func (c *Collector) SubscriptionLag(stream string, lag int64) {
+	c.subscriptionLag.WithLabelValues(stream).Set(float64(lag))
+}
+
+// Acked implements the goro.MetricsCollector interface. Acks are counted with the "ack" action.
+func (c *Collector) Acked(stream, subscriptionName string) {
+	c.acknowledged.WithLabelValues(c.category(stream), subscriptionName, "ack").Inc()
+}
+
+// Nacked implements the goro.MetricsCollector interface
+func (c *Collector) Nacked(stream, subscriptionName string, action goro.Action) {
+	c.acknowledged.WithLabelValues(c.category(stream), subscriptionName, string(action)).Inc()
+}
+
+// Reconnected implements the goro.MetricsCollector interface
+func (c *Collector) Reconnected() {
+	c.reconnects.Inc()
+}
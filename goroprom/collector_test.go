@@ -0,0 +1,99 @@
+package goroprom_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/vectorhacker/goro"
+	"github.com/vectorhacker/goro/estest"
+	"github.com/vectorhacker/goro/goroprom"
+)
+
+func TestCollector(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("it should export the metrics of a client", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+
+		collector := goroprom.NewCollector()
+		registry := prometheus.NewPedanticRegistry()
+		assert.Nil(t, registry.Register(collector))
+		client := s.Connect(goro.WithMetrics(collector))
+
+		assert.Nil(t, client.Writer("account-1").Write(ctx, goro.ExpectedVersionNone, goro.CreateEvent("test", []byte("{}"), nil, 0)))
+		assert.Nil(t, client.Writer("account-2").Write(ctx, goro.ExpectedVersionNone, goro.CreateEvent("test", []byte("{}"), nil, 0)))
+		_, err := client.FowardsReader("account-1").Read(ctx, 0, 10)
+		assert.Nil(t, err)
+		_, err = client.FowardsReader("missing").Read(ctx, 0, 10)
+		assert.True(t, errors.Is(err, goro.ErrStreamNotFound), "%v", err)
+
+		err = testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP goro_events_read_total The events read by readers and subscriptions, by stream category.
+# TYPE goro_events_read_total counter
+goro_events_read_total{category="account"} 1
+# HELP goro_events_written_total The events written, by stream category.
+# TYPE goro_events_written_total counter
+goro_events_written_total{category="account"} 2
+`), "goro_events_read_total", "goro_events_written_total")
+		assert.Nil(t, err)
+
+		count, err := testutil.GatherAndCount(registry, "goro_request_duration_seconds")
+		assert.Nil(t, err)
+		assert.Equal(t, 3, count)
+	})
+
+	t.Run("it should export subscription lag, acknowledgements and reconnects", func(t *testing.T) {
+		collector := goroprom.NewCollector()
+
+		collector.SubscriptionLag("$ce-tests", 42)
+		collector.SubscriptionLag("tests-1", 7)
+		collector.Acked("tests", "group")
+		collector.Acked("tests", "group")
+		collector.Nacked("tests", "group", goro.ActionPark)
+		collector.Reconnected()
+		collector.ObserveRequest(goro.OperationWrite, time.Millisecond, nil)
+
+		assert.Nil(t, testutil.CollectAndCompare(collector, strings.NewReader(`
+# HELP goro_acknowledgements_total The events acked or nacked in persistent subscriptions, by action.
+# TYPE goro_acknowledgements_total counter
+goro_acknowledgements_total{action="ack",category="tests",subscription="group"} 2
+goro_acknowledgements_total{action="park",category="tests",subscription="group"} 1
+# HELP goro_reconnects_total The requests sent to another node of the cluster because their node couldn't be reached.
+# TYPE goro_reconnects_total counter
+goro_reconnects_total 1
+# HELP goro_subscription_lag_events How many events the catchup subscriptions of a stream are behind its head.
+# TYPE goro_subscription_lag_events gauge
+goro_subscription_lag_events{stream="$ce-tests"} 42
+goro_subscription_lag_events{stream="tests-1"} 7
+`), "goro_acknowledgements_total", "goro_reconnects_total", "goro_subscription_lag_events"))
+	})
+
+	t.Run("it should find the category of streams", func(t *testing.T) {
+		assert.Equal(t, "account", goroprom.Category("account-1"))
+		assert.Equal(t, "account", goroprom.Category("$ce-account"))
+		assert.Equal(t, "deposited", goroprom.Category("$et-deposited"))
+		assert.Equal(t, "$all", goroprom.Category("$all"))
+	})
+
+	t.Run("it should map streams to categories", func(t *testing.T) {
+		collector := goroprom.NewCollector(goroprom.WithCategories(func(stream string) string {
+			return "all"
+		}))
+
+		collector.EventsWritten("account-1", 1)
+		collector.EventsWritten("order-1", 2)
+
+		assert.Nil(t, testutil.CollectAndCompare(collector, strings.NewReader(`
+# HELP goro_events_written_total The events written, by stream category.
+# TYPE goro_events_written_total counter
+goro_events_written_total{category="all"} 3
+`), "goro_events_written_total"))
+	})
+}
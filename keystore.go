@@ -0,0 +1,153 @@
+package goro
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const keySize = 32 // AES-256
+
+// KeyStore keeps the encryption key of each subject whose data is encrypted. Deleting the key of a
+// subject makes its encrypted data unreadable.
+type KeyStore interface {
+	// Key returns the key of a subject, or ErrKeyNotFound
+	Key(subject string) ([]byte, error)
+	// CreateKey returns the key of a subject, creating it if it doesn't exist
+	CreateKey(subject string) ([]byte, error)
+	// DeleteKey deletes the key of a subject
+	DeleteKey(subject string) error
+}
+
+func newKey() ([]byte, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// MemoryKeyStore is a KeyStore that keeps keys in memory, for use in tests
+type MemoryKeyStore struct {
+	mu   sync.Mutex
+	keys map[string][]byte
+}
+
+// NewMemoryKeyStore creates an empty MemoryKeyStore
+func NewMemoryKeyStore() *MemoryKeyStore {
+	return &MemoryKeyStore{
+		keys: map[string][]byte{},
+	}
+}
+
+// Key implements the KeyStore interface
+func (s *MemoryKeyStore) Key(subject string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[subject]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+
+	return key, nil
+}
+
+// CreateKey implements the KeyStore interface
+func (s *MemoryKeyStore) CreateKey(subject string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key, ok := s.keys[subject]; ok {
+		return key, nil
+	}
+
+	key, err := newKey()
+	if err != nil {
+		return nil, err
+	}
+	s.keys[subject] = key
+
+	return key, nil
+}
+
+// DeleteKey implements the KeyStore interface
+func (s *MemoryKeyStore) DeleteKey(subject string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.keys, subject)
+	return nil
+}
+
+// FileKeyStore is a KeyStore that keeps each key in its own file in a directory
+type FileKeyStore struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// NewFileKeyStore creates a FileKeyStore that keeps keys in dir, creating it if needed
+func NewFileKeyStore(dir string) (*FileKeyStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &FileKeyStore{
+		dir: dir,
+	}, nil
+}
+
+func (s *FileKeyStore) path(subject string) string {
+	return filepath.Join(s.dir, hex.EncodeToString([]byte(subject))+".key")
+}
+
+// Key implements the KeyStore interface
+func (s *FileKeyStore) Key(subject string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.key(subject)
+}
+
+func (s *FileKeyStore) key(subject string) ([]byte, error) {
+	key, err := os.ReadFile(s.path(subject))
+	if os.IsNotExist(err) {
+		return nil, ErrKeyNotFound
+	}
+
+	return key, err
+}
+
+// CreateKey implements the KeyStore interface
+func (s *FileKeyStore) CreateKey(subject string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, err := s.key(subject)
+	if err != ErrKeyNotFound {
+		return key, err
+	}
+
+	key, err = newKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return key, os.WriteFile(s.path(subject), key, 0600)
+}
+
+// DeleteKey implements the KeyStore interface
+func (s *FileKeyStore) DeleteKey(subject string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := os.Remove(s.path(subject))
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
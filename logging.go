@@ -0,0 +1,59 @@
+package goro
+
+import (
+	"context"
+	"net/url"
+)
+
+// Logger logs structured messages, with alternating keys and values like log/slog. *slog.Logger
+// implements it.
+type Logger interface {
+	DebugContext(ctx context.Context, msg string, args ...interface{})
+	InfoContext(ctx context.Context, msg string, args ...interface{})
+	WarnContext(ctx context.Context, msg string, args ...interface{})
+	ErrorContext(ctx context.Context, msg string, args ...interface{})
+}
+
+// WithLogger logs what the Client does: page fetches at the debug level, retries and reconnects as
+// warnings, and failed subscriptions and acks as errors. The Replicators of the Client log their
+// checkpoint saves. Credentials are never logged.
+func WithLogger(logger Logger) ClientOption {
+	return func(c *Client) {
+		if logger == nil {
+			c.invalid("the logger is nil")
+			return
+		}
+
+		c.log = logger
+	}
+}
+
+// logged is implemented by a Client created with WithLogger
+type logged interface {
+	logger() Logger
+}
+
+// loggerOf returns the Logger of a Client, or one that logs nothing. v is usually a Slinger or Streams.
+func loggerOf(v interface{}) Logger {
+	if l, ok := v.(logged); ok && l.logger() != nil {
+		return l.logger()
+	}
+
+	return noopLogger{}
+}
+
+// redact returns a url without its password, to be logged
+func redact(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+
+	return u.Redacted()
+}
+
+type noopLogger struct{}
+
+func (noopLogger) DebugContext(ctx context.Context, msg string, args ...interface{}) {}
+func (noopLogger) InfoContext(ctx context.Context, msg string, args ...interface{})  {}
+func (noopLogger) WarnContext(ctx context.Context, msg string, args ...interface{})  {}
+func (noopLogger) ErrorContext(ctx context.Context, msg string, args ...interface{}) {}
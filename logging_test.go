@@ -0,0 +1,185 @@
+package goro_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vectorhacker/goro"
+	"github.com/vectorhacker/goro/estest"
+	"github.com/vectorhacker/goro/memory"
+)
+
+// logBuffer collects the records of a slog.Logger as JSON lines
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *logBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// records returns the records with a message
+func (b *logBuffer) records(msg string) []map[string]interface{} {
+	records := []map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		record := map[string]interface{}{}
+		if json.Unmarshal([]byte(line), &record) == nil && record["msg"] == msg {
+			records = append(records, record)
+		}
+	}
+
+	return records
+}
+
+func newTestLogger() (*slog.Logger, *logBuffer) {
+	b := &logBuffer{}
+	return slog.New(slog.NewJSONHandler(b, &slog.HandlerOptions{Level: slog.LevelDebug})), b
+}
+
+func TestLogging(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("it should log retries without credentials", func(t *testing.T) {
+		s := newFlakyServer(t, 1, 503)
+		policy := goro.DefaultRetryPolicy()
+		policy.Backoff = func(int) time.Duration {
+			return time.Millisecond
+		}
+		logger, logs := newTestLogger()
+		host := strings.Replace(s.URL, "http://", "http://admin:changeit@", 1)
+		client, err := goro.NewClient(host, goro.WithBasicAuth("admin", "changeit"), goro.WithRetryPolicy(policy), goro.WithLogger(logger))
+		assert.Nil(t, err)
+
+		_, err = client.FowardsReader("tests").Read(ctx, 0, 1)
+		assert.Nil(t, err)
+
+		retries := logs.records("retrying request")
+		assert.Len(t, retries, 1)
+		assert.Equal(t, "WARN", retries[0]["level"])
+		assert.Equal(t, float64(503), retries[0]["status"])
+		assert.Equal(t, float64(1), retries[0]["attempt"])
+		assert.NotContains(t, logs.String(), "changeit")
+	})
+
+	t.Run("it should log page fetches", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+		logger, logs := newTestLogger()
+		client := s.Connect(goro.WithLogger(logger))
+
+		assert.Nil(t, client.Writer("tests").Write(ctx, goro.ExpectedVersionNone, testEvents(3)...))
+		_, err := client.FowardsReader("tests").Read(ctx, 0, 3)
+		assert.Nil(t, err)
+
+		pages := logs.records("fetched page")
+		assert.Len(t, pages, 1)
+		assert.Equal(t, "DEBUG", pages[0]["level"])
+		assert.Equal(t, "tests", pages[0]["stream"])
+		assert.Equal(t, float64(3), pages[0]["events"])
+	})
+
+	t.Run("it should log failed subscriptions", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+		logger, logs := newTestLogger()
+		client := s.Connect(goro.WithLogger(logger))
+
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		message := <-client.CatchupSubscription("missing", 0).Subscribe(ctx)
+		assert.True(t, errors.Is(message.Error, goro.ErrStreamNotFound), "%v", message.Error)
+
+		failures := logs.records("catchup subscription failed")
+		assert.Len(t, failures, 1)
+		assert.Equal(t, "ERROR", failures[0]["level"])
+		assert.Equal(t, "missing", failures[0]["stream"])
+		assert.Equal(t, message.Error.Error(), failures[0]["error"])
+	})
+
+	t.Run("it should log failed acks", func(t *testing.T) {
+		s := estest.NewServer()
+		logger, logs := newTestLogger()
+		client := s.Connect(goro.WithLogger(logger))
+
+		assert.Nil(t, client.Writer("tests").Write(ctx, goro.ExpectedVersionNone, testEvents(1)...))
+		subscription, err := client.PersistentSubscription("tests", "group", goro.PersistentSubscriptionSettings{})
+		assert.Nil(t, err)
+
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		message := <-subscription.Subscribe(ctx)
+		assert.Nil(t, message.Error)
+
+		s.Close()
+		assert.NotNil(t, message.Ack())
+
+		failures := logs.records("ack failed")
+		assert.Len(t, failures, 1)
+		assert.Equal(t, "ERROR", failures[0]["level"])
+		assert.Equal(t, "group", failures[0]["subscription"])
+		assert.Equal(t, message.Event.ID.String(), failures[0]["event"])
+	})
+
+	t.Run("it should log reconnects", func(t *testing.T) {
+		c := newFakeCluster(t, "Follower", "Leader")
+		logger, logs := newTestLogger()
+		client := goro.Connect(c.nodes[0].URL, goro.WithGossipSeeds(c.host(1)), goro.WithLogger(logger))
+		assert.Nil(t, client.Writer("tests").Write(ctx, goro.ExpectedVersionAny, testEvents(1)...))
+
+		c.setState(0, "Leader")
+		c.setState(1, "Dead")
+		c.nodes[1].Close()
+
+		assert.Nil(t, client.Writer("tests").Write(ctx, goro.ExpectedVersionAny, testEvents(1)...))
+		reconnects := logs.records("reconnecting to another node")
+		assert.Len(t, reconnects, 1)
+		assert.Equal(t, "WARN", reconnects[0]["level"])
+		assert.Equal(t, c.host(0), reconnects[0]["node"])
+	})
+
+	t.Run("it should log checkpoint saves", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+		logger, logs := newTestLogger()
+		source := s.Connect(goro.WithLogger(logger))
+		target := memory.NewStore()
+		assert.Nil(t, source.Writer("tests").Write(ctx, goro.ExpectedVersionNone, testEvents(2)...))
+
+		ctx, cancel := context.WithCancel(ctx)
+		done := make(chan error)
+		go func() {
+			done <- goro.NewReplicator(source, target, "tests", goro.WithCheckpointEvery(1, 0)).Run(ctx)
+		}()
+		assert.Eventually(t, func() bool {
+			return len(logs.records("saved checkpoint")) == 2
+		}, time.Second, 5*time.Millisecond)
+		cancel()
+		assert.Equal(t, context.Canceled, <-done)
+
+		saves := logs.records("saved checkpoint")
+		assert.Equal(t, "DEBUG", saves[1]["level"])
+		assert.Equal(t, float64(1), saves[1]["position"])
+	})
+
+	t.Run("it should reject a nil logger", func(t *testing.T) {
+		_, err := goro.NewClient("http://localhost:2113", goro.WithLogger(nil))
+		assert.True(t, errors.Is(err, goro.ErrInvalidOption), "%v", err)
+	})
+}
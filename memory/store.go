@@ -0,0 +1,351 @@
+// Package memory implements an in-memory Event Store for tests. A Store provides the same Writers,
+// Readers and Subscribers as a goro.Client, enforces expected versions, and supports the $all stream and
+// $ce- category streams, so that code using goro can be tested quickly and deterministically.
+package memory
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/vectorhacker/goro"
+)
+
+const (
+	allStream      = "$all"
+	categoryPrefix = "$ce-"
+)
+
+type stream struct {
+	events    goro.Events
+	metadata  goro.StreamMetadata
+	tombstone bool
+}
+
+// Store is an in-memory Event Store
+type Store struct {
+	mu            sync.Mutex
+	streams       map[string]*stream
+	all           goro.Events
+	changed       chan struct{}
+	subscriptions map[string]*persistentGroup
+}
+
+// NewStore creates an empty Store
+func NewStore() *Store {
+	return &Store{
+		streams:       map[string]*stream{},
+		changed:       make(chan struct{}),
+		subscriptions: map[string]*persistentGroup{},
+	}
+}
+
+// notify wakes up the subscriptions waiting for changes. The lock must be held.
+func (s *Store) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// category returns the category of a stream, which is the part of its name before the first dash
+func category(name string) string {
+	if i := strings.Index(name, "-"); i >= 0 {
+		return name[:i]
+	}
+
+	return name
+}
+
+// view returns the readable Events of a stream, in order, and whether the stream exists. Each Event
+// carries its position in the stream that is read. The lock must be held.
+func (s *Store) view(name string) (goro.Events, bool) {
+	if name == allStream {
+		events := make(goro.Events, len(s.all))
+		for i, event := range s.all {
+			event.PositionStream = name
+			event.Position = int64(i)
+			events[i] = event
+		}
+
+		return events, true
+	}
+
+	if strings.HasPrefix(name, categoryPrefix) {
+		c := strings.TrimPrefix(name, categoryPrefix)
+		events := goro.Events{}
+		for _, event := range s.all {
+			if category(event.Stream) != c {
+				continue
+			}
+
+			event.PositionStream = name
+			event.Position = int64(len(events))
+			events = append(events, event)
+		}
+
+		return events, len(events) > 0
+	}
+
+	st, ok := s.streams[name]
+	if !ok || st.tombstone || len(st.events) == 0 {
+		return nil, false
+	}
+
+	events := st.events
+	if maxCount := st.metadata.MaxCount; maxCount > 0 && int64(len(events)) > maxCount {
+		events = events[int64(len(events))-maxCount:]
+	}
+	if tb := st.metadata.TruncateBefore; tb != nil {
+		for len(events) > 0 && events[0].Version < *tb {
+			events = events[1:]
+		}
+	}
+
+	return append(goro.Events{}, events...), len(events) > 0
+}
+
+// deleted reports whether a stream was hard deleted. The lock must be held.
+func (s *Store) deleted(name string) bool {
+	st, ok := s.streams[name]
+	return ok && st.tombstone
+}
+
+// Writer creates a new Writer for a stream
+func (s *Store) Writer(stream string) goro.Writer {
+	return &writer{
+		store:  s,
+		stream: stream,
+	}
+}
+
+// FowardsReader creates a new Reader that reads forwards on a stream
+func (s *Store) FowardsReader(stream string) goro.Reader {
+	return &reader{
+		store:  s,
+		stream: stream,
+	}
+}
+
+// BackwardsReader creates a new Reader that reads backwards on a stream. Like the Readers of a goro.Client,
+// reads begin at the event numbered start+1, or at the last event for goro.StreamHead.
+func (s *Store) BackwardsReader(stream string) goro.Reader {
+	return &reader{
+		store:     s,
+		stream:    stream,
+		backwards: true,
+	}
+}
+
+// SetStreamMetadata replaces the metadata of a stream. The $maxCount and $tb settings are enforced when
+// reading the stream.
+func (s *Store) SetStreamMetadata(ctx context.Context, name string, metadata goro.StreamMetadata) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.streams[name]
+	if !ok {
+		st = &stream{}
+		s.streams[name] = st
+	}
+	st.metadata = metadata
+
+	return nil
+}
+
+// StreamMetadata returns the metadata of a stream
+func (s *Store) StreamMetadata(ctx context.Context, name string) (goro.StreamMetadata, error) {
+	if err := ctx.Err(); err != nil {
+		return goro.StreamMetadata{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.streams[name]
+	if !ok {
+		return goro.StreamMetadata{}, nil
+	}
+
+	return st.metadata, nil
+}
+
+// DeleteStream deletes a stream. Soft deleting truncates the stream, so that it reads as not found until
+// Events are written to it again. Hard deleting leaves a tombstone, and the stream can't be written to or
+// read again.
+func (s *Store) DeleteStream(ctx context.Context, name string, hard bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.streams[name]
+	if !ok {
+		st = &stream{}
+		s.streams[name] = st
+	}
+	if st.tombstone {
+		return goro.ErrStreamDeleted
+	}
+
+	if hard {
+		st.tombstone = true
+	} else {
+		tb := int64(len(st.events))
+		st.metadata.TruncateBefore = &tb
+	}
+	s.notify()
+
+	return nil
+}
+
+// Repository creates a new goro.Repository that keeps its Aggregates in the Store
+func (s *Store) Repository(factory goro.AggregateFactory, options ...goro.RepositoryOption) *goro.Repository {
+	return goro.NewRepository(nil, factory, append([]goro.RepositoryOption{goro.WithStreams(s)}, options...)...)
+}
+
+type writer struct {
+	store  *Store
+	stream string
+}
+
+// Write implements the goro.Writer interface
+func (w *writer) Write(ctx context.Context, expectedVersion int64, events ...goro.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data := append(goro.Events{}, events...)
+	sort.Sort(data)
+	for i, event := range data {
+		var err error
+		data[i], err = goro.Correlate(ctx, event)
+		if err != nil {
+			return err
+		}
+	}
+
+	w.store.mu.Lock()
+	defer w.store.mu.Unlock()
+
+	st, ok := w.store.streams[w.stream]
+	if !ok {
+		st = &stream{}
+		w.store.streams[w.stream] = st
+	}
+	if st.tombstone {
+		return goro.ErrStreamDeleted
+	}
+
+	if written(st.events, data) {
+		return nil
+	}
+
+	current := int64(len(st.events)) - 1
+	switch expectedVersion {
+	case goro.ExpectedVersionAny:
+	case goro.ExpectedVersionNone:
+		if current != goro.ExpectedVersionNone {
+			return goro.ErrWrongExpectedVersion
+		}
+	default:
+		if current != expectedVersion {
+			return goro.ErrWrongExpectedVersion
+		}
+	}
+
+	for _, event := range data {
+		current++
+		event.Stream = w.stream
+		event.Version = current
+		event.PositionStream = w.stream
+		event.Position = current
+		event.Data = append([]byte(nil), event.Data...)
+		event.Metadata = append([]byte(nil), event.Metadata...)
+		if event.At.IsZero() {
+			event.At = time.Now()
+		}
+
+		st.events = append(st.events, event)
+		w.store.all = append(w.store.all, event)
+	}
+	w.store.notify()
+
+	return nil
+}
+
+// written reports whether all events have already been written to a stream, in which case writing them
+// again is a no-op, like in Event Store.
+func written(stream goro.Events, events goro.Events) bool {
+	if len(events) == 0 {
+		return false
+	}
+
+	ids := map[uuid.UUID]bool{}
+	for _, event := range stream {
+		ids[event.ID] = true
+	}
+
+	for _, event := range events {
+		if !ids[event.ID] {
+			return false
+		}
+	}
+
+	return true
+}
+
+type reader struct {
+	store     *Store
+	stream    string
+	backwards bool
+}
+
+// Read implements the goro.Reader interface
+func (r *reader) Read(ctx context.Context, start int64, count int) (goro.Events, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.Lock()
+	view, ok := r.store.view(r.stream)
+	deleted := r.store.deleted(r.stream)
+	r.store.mu.Unlock()
+	if deleted {
+		return nil, goro.ErrStreamDeleted
+	}
+	if !ok {
+		return nil, goro.ErrStreamNotFound
+	}
+
+	events := goro.Events{}
+	if r.backwards {
+		if start == goro.StreamHead || start == math.MaxInt64 {
+			start = math.MaxInt64
+		} else {
+			start++
+		}
+
+		for i := len(view) - 1; i >= 0 && len(events) < count; i-- {
+			if view[i].Position <= start {
+				events = append(events, view[i])
+			}
+		}
+	} else {
+		for i := 0; i < len(view) && len(events) < count; i++ {
+			if view[i].Position >= start {
+				events = append(events, view[i])
+			}
+		}
+	}
+
+	return events, nil
+}
@@ -0,0 +1,263 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vectorhacker/goro"
+	"github.com/vectorhacker/goro/memory"
+)
+
+func generateEvents(count int) goro.Events {
+	events := make(goro.Events, count)
+	for i := range events {
+		events[i] = goro.CreateEvent("deposit", []byte("{\"double\":\"trouble\"}"), nil, int64(i))
+	}
+
+	return events
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("it should write and read a stream", func(t *testing.T) {
+		store := memory.NewStore()
+
+		err := store.Writer("account-1").Write(ctx, goro.ExpectedVersionNone, generateEvents(3)...)
+		assert.Nil(t, err)
+
+		events, err := store.FowardsReader("account-1").Read(ctx, 1, 10)
+		assert.Nil(t, err)
+		assert.Len(t, events, 2)
+		assert.Equal(t, int64(1), events[0].Version)
+		assert.Equal(t, "account-1", events[0].Stream)
+
+		events, err = store.BackwardsReader("account-1").Read(ctx, goro.StreamHead, 2)
+		assert.Nil(t, err)
+		assert.Len(t, events, 2)
+		assert.Equal(t, int64(2), events[0].Version)
+		assert.Equal(t, int64(1), events[1].Version)
+
+		// like the Readers of a goro.Client, backwards reads begin after start
+		events, err = store.BackwardsReader("account-1").Read(ctx, 0, 10)
+		assert.Nil(t, err)
+		assert.Len(t, events, 2)
+		assert.Equal(t, int64(1), events[0].Version)
+		assert.Equal(t, int64(0), events[1].Version)
+	})
+
+	t.Run("it should enforce expected versions", func(t *testing.T) {
+		store := memory.NewStore()
+		writer := store.Writer("account-1")
+
+		assert.Nil(t, writer.Write(ctx, goro.ExpectedVersionNone, generateEvents(2)...))
+		assert.Equal(t, goro.ErrWrongExpectedVersion, writer.Write(ctx, goro.ExpectedVersionNone, generateEvents(1)...))
+		assert.Equal(t, goro.ErrWrongExpectedVersion, writer.Write(ctx, 0, generateEvents(1)...))
+		assert.Nil(t, writer.Write(ctx, 1, generateEvents(1)...))
+		assert.Nil(t, writer.Write(ctx, goro.ExpectedVersionAny, generateEvents(1)...))
+	})
+
+	t.Run("it should ignore events that were already written", func(t *testing.T) {
+		store := memory.NewStore()
+		events := generateEvents(2)
+
+		assert.Nil(t, store.Writer("account-1").Write(ctx, goro.ExpectedVersionNone, events...))
+		assert.Nil(t, store.Writer("account-1").Write(ctx, goro.ExpectedVersionNone, events...))
+
+		read, err := store.FowardsReader("account-1").Read(ctx, 0, 10)
+		assert.Nil(t, err)
+		assert.Len(t, read, 2)
+	})
+
+	t.Run("it should fail to read missing streams", func(t *testing.T) {
+		store := memory.NewStore()
+
+		_, err := store.FowardsReader("account-1").Read(ctx, 0, 10)
+		assert.Equal(t, goro.ErrStreamNotFound, err)
+	})
+
+	t.Run("it should read the $all and category streams", func(t *testing.T) {
+		store := memory.NewStore()
+		assert.Nil(t, store.Writer("account-1").Write(ctx, goro.ExpectedVersionAny, generateEvents(2)...))
+		assert.Nil(t, store.Writer("customer-1").Write(ctx, goro.ExpectedVersionAny, generateEvents(1)...))
+		assert.Nil(t, store.Writer("account-2").Write(ctx, goro.ExpectedVersionAny, generateEvents(1)...))
+
+		all, err := store.FowardsReader("$all").Read(ctx, 0, 10)
+		assert.Nil(t, err)
+		assert.Len(t, all, 4)
+
+		accounts, err := store.FowardsReader("$ce-account").Read(ctx, 1, 10)
+		assert.Nil(t, err)
+		assert.Len(t, accounts, 2)
+		assert.Equal(t, "account-1", accounts[0].Stream)
+		assert.Equal(t, int64(1), accounts[0].Version)
+		assert.Equal(t, "account-2", accounts[1].Stream)
+		assert.Equal(t, int64(2), accounts[1].Position)
+	})
+
+	t.Run("it should enforce the max count of a stream", func(t *testing.T) {
+		store := memory.NewStore()
+		assert.Nil(t, store.SetStreamMetadata(ctx, "account-1-snapshot", goro.StreamMetadata{MaxCount: 1}))
+		assert.Nil(t, store.Writer("account-1-snapshot").Write(ctx, goro.ExpectedVersionAny, generateEvents(3)...))
+
+		events, err := store.FowardsReader("account-1-snapshot").Read(ctx, 0, 10)
+		assert.Nil(t, err)
+		assert.Len(t, events, 1)
+		assert.Equal(t, int64(2), events[0].Version)
+	})
+
+	t.Run("it should soft delete a stream", func(t *testing.T) {
+		store := memory.NewStore()
+		assert.Nil(t, store.Writer("account-1").Write(ctx, goro.ExpectedVersionNone, generateEvents(3)...))
+		assert.Nil(t, store.DeleteStream(ctx, "account-1", false))
+
+		_, err := store.FowardsReader("account-1").Read(ctx, 0, 10)
+		assert.Equal(t, goro.ErrStreamNotFound, err)
+
+		assert.Nil(t, store.Writer("account-1").Write(ctx, goro.ExpectedVersionAny, generateEvents(1)...))
+		events, err := store.FowardsReader("account-1").Read(ctx, 0, 10)
+		assert.Nil(t, err)
+		assert.Len(t, events, 1)
+		assert.Equal(t, int64(3), events[0].Version)
+	})
+
+	t.Run("it should hard delete a stream", func(t *testing.T) {
+		store := memory.NewStore()
+		assert.Nil(t, store.Writer("account-1").Write(ctx, goro.ExpectedVersionNone, generateEvents(3)...))
+		assert.Nil(t, store.DeleteStream(ctx, "account-1", true))
+
+		_, err := store.FowardsReader("account-1").Read(ctx, 0, 10)
+		assert.Equal(t, goro.ErrStreamDeleted, err)
+		err = store.Writer("account-1").Write(ctx, goro.ExpectedVersionAny, generateEvents(1)...)
+		assert.Equal(t, goro.ErrStreamDeleted, err)
+		assert.Equal(t, goro.ErrStreamDeleted, store.DeleteStream(ctx, "account-1", true))
+	})
+}
+
+func TestCatchupSubscription(t *testing.T) {
+	store := memory.NewStore()
+	assert.Nil(t, store.Writer("account-1").Write(context.Background(), goro.ExpectedVersionAny, generateEvents(2)...))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	messages := store.CatchupSubscription("$ce-account", 1).Subscribe(ctx)
+
+	message := <-messages
+	assert.Nil(t, message.Error)
+	assert.Equal(t, int64(1), message.Event.Version)
+
+	assert.Nil(t, store.Writer("account-2").Write(context.Background(), goro.ExpectedVersionAny, generateEvents(1)...))
+
+	message = <-messages
+	assert.Nil(t, message.Error)
+	assert.Equal(t, "account-2", message.Event.Stream)
+
+	cancel()
+	for range messages {
+	}
+}
+
+func TestPersistentSubscription(t *testing.T) {
+	store := memory.NewStore()
+	assert.Nil(t, store.Writer("account-1").Write(context.Background(), goro.ExpectedVersionAny, generateEvents(3)...))
+
+	subscription, err := store.PersistentSubscription("account-1", "testing", goro.PersistentSubscriptionSettings{
+		MaxRetryCount: 1,
+	})
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	messages := subscription.Subscribe(ctx)
+
+	// acknowledge the first event, and keep failing the second one
+	message := <-messages
+	assert.Equal(t, int64(0), message.Event.Version)
+	assert.Nil(t, message.Ack())
+
+	message = <-messages
+	assert.Equal(t, int64(1), message.Event.Version)
+	assert.Nil(t, message.Nack(goro.ActionRetry))
+
+	// a competing consumer shares the events of the subscription
+	competing, err := store.PersistentSubscription("account-1", "testing", goro.PersistentSubscriptionSettings{})
+	assert.Nil(t, err)
+	other := competing.Subscribe(ctx)
+
+	versions := []int64{}
+	for len(versions) < 2 {
+		select {
+		case message = <-messages:
+		case message = <-other:
+		}
+
+		versions = append(versions, message.Event.Version)
+		if message.Event.Version == 1 {
+			assert.Nil(t, message.Nack(goro.ActionRetry))
+		} else {
+			assert.Nil(t, message.Ack())
+		}
+	}
+	assert.ElementsMatch(t, []int64{1, 2}, versions)
+
+	parked := store.Parked("account-1", "testing")
+	assert.Len(t, parked, 1)
+	assert.Equal(t, int64(1), parked[0].Version)
+}
+
+func TestPersistentSubscriptionFailIfExists(t *testing.T) {
+	store := memory.NewStore()
+
+	_, err := store.PersistentSubscription("account-1", "testing", goro.PersistentSubscriptionSettings{}, goro.FailIfExists())
+	assert.Nil(t, err)
+	_, err = store.PersistentSubscription("account-1", "testing", goro.PersistentSubscriptionSettings{}, goro.FailIfExists())
+	assert.True(t, errors.Is(err, goro.ErrConflict), "%v", err)
+}
+
+func TestRepository(t *testing.T) {
+	store := memory.NewStore()
+	repository := store.Repository(func(id string) goro.Aggregate {
+		return &counter{version: goro.ExpectedVersionNone}
+	})
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		err := repository.Update(ctx, "counter-1", func(aggregate goro.Aggregate) error {
+			aggregate.(*counter).increment()
+			return nil
+		})
+		assert.Nil(t, err)
+	}
+
+	aggregate, err := repository.Load(ctx, "counter-1")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, aggregate.(*counter).count)
+	assert.Equal(t, int64(2), aggregate.Version())
+}
+
+type counter struct {
+	version int64
+	count   int
+	changes goro.Events
+}
+
+func (c *counter) Apply(event goro.Event) {
+	c.count++
+	c.version = event.Version
+}
+
+func (c *counter) Changes() goro.Events {
+	return c.changes
+}
+
+func (c *counter) Version() int64 {
+	return c.version
+}
+
+func (c *counter) increment() {
+	c.changes = append(c.changes, goro.CreateEvent("incremented", []byte("{}"), nil, 0))
+}
@@ -0,0 +1,246 @@
+package memory
+
+import (
+	"context"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/vectorhacker/goro"
+)
+
+// CatchupSubscription creates a new catchup style subscription that starts reading at an event number and
+// continues forwards
+func (s *Store) CatchupSubscription(stream string, start int64) goro.Subscriber {
+	return &catchupSubscription{
+		store:  s,
+		stream: stream,
+		start:  start,
+	}
+}
+
+type catchupSubscription struct {
+	store  *Store
+	stream string
+	start  int64
+}
+
+// Subscribe implements the goro.Subscriber interface
+func (c *catchupSubscription) Subscribe(ctx context.Context) <-chan goro.StreamMessage {
+	stream := make(chan goro.StreamMessage)
+
+	go func() {
+		defer close(stream)
+		next := c.start
+
+		for {
+			c.store.mu.Lock()
+			view, _ := c.store.view(c.stream)
+			changed := c.store.changed
+			c.store.mu.Unlock()
+
+			for _, event := range view {
+				if event.Position < next {
+					continue
+				}
+				next = event.Position + 1
+
+				select {
+				case <-ctx.Done():
+					return
+				case stream <- goro.StreamMessage{Event: event}:
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-changed:
+			}
+		}
+	}()
+
+	return stream
+}
+
+// persistentGroup is the state shared by the competing consumers of a persistent subscription
+type persistentGroup struct {
+	stream   string
+	settings goro.PersistentSubscriptionSettings
+	next     int64
+	retries  goro.Events
+	retried  map[uuid.UUID]int
+	inFlight map[uuid.UUID]goro.Event
+	parked   goro.Events
+}
+
+func subscriptionKey(stream, subscriptionName string) string {
+	return stream + "::" + subscriptionName
+}
+
+// PersistentSubscription creates a new competing consumer style subscription with the given settings.
+// Subscribers of the same stream and subscription name share its Events: like a goro.Client, it joins
+// the subscription when it already exists, unless goro.FailIfExists is passed.
+func (s *Store) PersistentSubscription(stream, subscriptionName string, settings goro.PersistentSubscriptionSettings, options ...goro.PersistentSubscriptionOption) (goro.Subscriber, error) {
+	opts := goro.PersistentSubscriptionOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := subscriptionKey(stream, subscriptionName)
+	group, ok := s.subscriptions[key]
+	if ok && opts.FailIfExists {
+		return nil, goro.ErrConflict
+	}
+	if !ok {
+		group = &persistentGroup{
+			stream:   stream,
+			settings: settings,
+			next:     settings.StartFrom,
+			retried:  map[uuid.UUID]int{},
+			inFlight: map[uuid.UUID]goro.Event{},
+		}
+		s.subscriptions[key] = group
+	}
+
+	return &persistentSubscription{
+		store: s,
+		group: group,
+	}, nil
+}
+
+// Parked returns the Events parked by the consumers of a persistent subscription
+func (s *Store) Parked(stream, subscriptionName string) goro.Events {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	group, ok := s.subscriptions[subscriptionKey(stream, subscriptionName)]
+	if !ok {
+		return nil
+	}
+
+	return append(goro.Events{}, group.parked...)
+}
+
+type persistentSubscription struct {
+	store *Store
+	group *persistentGroup
+}
+
+// dispatch takes the next Event for a consumer of the group, and reports whether there was one. The lock
+// must be held.
+func (p *persistentSubscription) dispatch() (goro.Event, bool) {
+	group := p.group
+	if len(group.retries) > 0 {
+		event := group.retries[0]
+		group.retries = group.retries[1:]
+		group.inFlight[event.ID] = event
+		return event, true
+	}
+
+	view, _ := p.store.view(group.stream)
+	for _, event := range view {
+		if event.Position < group.next {
+			continue
+		}
+
+		group.next = event.Position + 1
+		group.inFlight[event.ID] = event
+		return event, true
+	}
+
+	return goro.Event{}, false
+}
+
+// Subscribe implements the goro.Subscriber interface
+func (p *persistentSubscription) Subscribe(ctx context.Context) <-chan goro.StreamMessage {
+	stream := make(chan goro.StreamMessage)
+
+	go func() {
+		defer close(stream)
+
+		for {
+			p.store.mu.Lock()
+			event, ok := p.dispatch()
+			changed := p.store.changed
+			p.store.mu.Unlock()
+
+			if !ok {
+				select {
+				case <-ctx.Done():
+					return
+				case <-changed:
+				}
+				continue
+			}
+
+			message := goro.StreamMessage{
+				Event: event,
+				Acknowledger: &acknowledger{
+					subscription: p,
+					eventID:      event.ID,
+				},
+			}
+
+			select {
+			case <-ctx.Done():
+				// give the Event to another consumer
+				message.Acknowledger.Nack(goro.ActionRetry)
+				return
+			case stream <- message:
+			}
+		}
+	}()
+
+	return stream
+}
+
+type acknowledger struct {
+	subscription *persistentSubscription
+	eventID      uuid.UUID
+}
+
+// Ack implements the goro.Acknowledger interface
+func (a *acknowledger) Ack() error {
+	store := a.subscription.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	group := a.subscription.group
+	delete(group.inFlight, a.eventID)
+	delete(group.retried, a.eventID)
+	return nil
+}
+
+// Nack implements the goro.Acknowledger interface. Retried Events are parked once they exceed the
+// MaxRetryCount of the subscription.
+func (a *acknowledger) Nack(action goro.Action) error {
+	store := a.subscription.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	group := a.subscription.group
+	event, ok := group.inFlight[a.eventID]
+	if !ok {
+		return nil
+	}
+	delete(group.inFlight, a.eventID)
+
+	if action == goro.ActionRetry {
+		group.retried[a.eventID]++
+		if maxRetries := group.settings.MaxRetryCount; maxRetries == 0 || group.retried[a.eventID] <= maxRetries {
+			group.retries = append(group.retries, event)
+			store.notify()
+			return nil
+		}
+		action = goro.ActionPark
+	}
+
+	delete(group.retried, a.eventID)
+	if action == goro.ActionPark {
+		group.parked = append(group.parked, event)
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package goro
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	metadataPath      = "/streams/%s/metadata"
+	metadataEventType = "$metadata"
+)
+
+// StreamMetadata represents the reserved metadata Event Store uses to manage a stream. You can read more
+// about it in the Event Store documentation [here](https://eventstore.org/docs/http-api/4.0.2/stream-metadata/).
+type StreamMetadata struct {
+	MaxCount       int64  `json:"$maxCount,omitempty"`
+	MaxAge         int64  `json:"$maxAge,omitempty"`
+	TruncateBefore *int64 `json:"$tb,omitempty"`
+	CacheControl   int64  `json:"$cacheControl,omitempty"`
+}
+
+// SetStreamMetadata replaces the metadata of a stream
+func SetStreamMetadata(ctx context.Context, slinger Slinger, stream string, metadata StreamMetadata) error {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	b := new(bytes.Buffer)
+	event := CreateEvent(metadataEventType, data, nil, 0)
+	if err := json.NewEncoder(b).Encode(Events{event}); err != nil {
+		return err
+	}
+
+	req, err := slinger.
+		Sling().
+		Post(fmt.Sprintf(metadataPath, stream)).
+		Body(b).
+		Set("Content-Type", eventContentType).
+		Request()
+	if err != nil {
+		return err
+	}
+
+	// the metadata event has an id, so it can be retried
+	req = req.WithContext(idempotent(ctx))
+
+	res, err := slinger.Sling().Do(req, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	return relevantError(res)
+}
+
+// ReadStreamMetadata reads the metadata of a stream. Streams without metadata have an empty StreamMetadata.
+func ReadStreamMetadata(ctx context.Context, slinger Slinger, stream string) (StreamMetadata, error) {
+	req, err := slinger.
+		Sling().
+		Get(fmt.Sprintf(metadataPath, stream)).
+		Set("Accept", jsonContentType).
+		Request()
+	if err != nil {
+		return StreamMetadata{}, err
+	}
+
+	req = req.WithContext(ctx)
+
+	metadata := StreamMetadata{}
+	res, err := slinger.Sling().Do(req, &metadata, nil)
+	if err != nil {
+		return StreamMetadata{}, err
+	}
+
+	if err := relevantError(res); err != nil {
+		return StreamMetadata{}, err
+	}
+
+	return metadata, nil
+}
+
+// setMetadataValue sets a key of the json object in metadata, creating the object if there is no metadata
+func setMetadataValue(metadata json.RawMessage, key string, value interface{}) (json.RawMessage, error) {
+	fields := map[string]json.RawMessage{}
+	if len(metadata) > 0 {
+		if err := json.Unmarshal(metadata, &fields); err != nil {
+			return nil, err
+		}
+		if fields == nil {
+			fields = map[string]json.RawMessage{}
+		}
+	}
+
+	v, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	fields[key] = v
+
+	return json.Marshal(fields)
+}
+
+// metadataValue decodes a key of the json object in metadata into v, and reports whether the key was set.
+// Metadata that isn't a json object, like a string written by another client, has no keys.
+func metadataValue(metadata json.RawMessage, key string, v interface{}) (bool, error) {
+	if len(metadata) == 0 {
+		return false, nil
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(metadata, &fields); err != nil {
+		return false, nil
+	}
+
+	value, ok := fields[key]
+	if !ok {
+		return false, nil
+	}
+
+	return true, json.Unmarshal(value, v)
+}
+
+// deleteMetadataValues deletes keys from the json object in metadata, dropping the metadata once it's empty
+func deleteMetadataValues(metadata json.RawMessage, keys ...string) (json.RawMessage, error) {
+	if len(metadata) == 0 {
+		return metadata, nil
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(metadata, &fields); err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		delete(fields, key)
+	}
+
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	return json.Marshal(fields)
+}
@@ -0,0 +1,88 @@
+package goro
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Operations, as they're reported to a MetricsCollector
+const (
+	OperationWrite            = "write"
+	OperationRead             = "read"
+	OperationSubscriptionPoll = "subscription_poll"
+	OperationAck              = "ack"
+	OperationNack             = "nack"
+)
+
+// MetricsCollector collects the metrics of a Client. The goroprom package has an adapter that exports
+// them to Prometheus.
+type MetricsCollector interface {
+	// ObserveRequest records how long an operation took, and whether it failed
+	ObserveRequest(operation string, duration time.Duration, err error)
+	// EventsWritten counts the Events written to a stream
+	EventsWritten(stream string, count int)
+	// EventsRead counts the Events read from a stream, by Readers and Subscribers
+	EventsRead(stream string, count int)
+	// SubscriptionLag records how many Events a catchup subscription is behind the head of its stream
+	SubscriptionLag(stream string, lag int64)
+	// Acked counts the Events acknowledged in a persistent subscription
+	Acked(stream, subscriptionName string)
+	// Nacked counts the Events rejected in a persistent subscription, by the Action taken
+	Nacked(stream, subscriptionName string, action Action)
+	// Reconnected counts the times a request was sent to another node of a cluster, because its node
+	// couldn't be reached
+	Reconnected()
+}
+
+// WithMetrics collects the metrics of the Client
+func WithMetrics(collector MetricsCollector) ClientOption {
+	return func(c *Client) {
+		if collector == nil {
+			c.invalid("the metrics collector is nil")
+			return
+		}
+
+		c.collector = collector
+	}
+}
+
+// instrumented is a Slinger that collects metrics, like a Client created with WithMetrics
+type instrumented interface {
+	metrics() MetricsCollector
+}
+
+// metricsOf returns the MetricsCollector of a Slinger, or one that does nothing
+func metricsOf(slinger Slinger) MetricsCollector {
+	if i, ok := slinger.(instrumented); ok && i.metrics() != nil {
+		return i.metrics()
+	}
+
+	return noopMetrics{}
+}
+
+// head returns the version of the last Event of the stream, from the ETag of the feed, which Event Store
+// sets to the version and a hash separated by a semicolon
+func (f feed) head() (int64, bool) {
+	version, _, found := strings.Cut(f.ETag, ";")
+	if !found {
+		return 0, false
+	}
+
+	head, err := strconv.ParseInt(version, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return head, true
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveRequest(operation string, duration time.Duration, err error) {}
+func (noopMetrics) EventsWritten(stream string, count int)                             {}
+func (noopMetrics) EventsRead(stream string, count int)                                {}
+func (noopMetrics) SubscriptionLag(stream string, lag int64)                           {}
+func (noopMetrics) Acked(stream, subscriptionName string)                              {}
+func (noopMetrics) Nacked(stream, subscriptionName string, action Action)              {}
+func (noopMetrics) Reconnected()                                                       {}
@@ -0,0 +1,190 @@
+package goro_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vectorhacker/goro"
+	"github.com/vectorhacker/goro/estest"
+)
+
+// recordingMetrics is a MetricsCollector that keeps what it collects
+type recordingMetrics struct {
+	mu           sync.Mutex
+	operations   map[string]int
+	failures     map[string]int
+	written      map[string]int
+	read         map[string]int
+	lag          map[string]int64
+	acknowledged map[string]int
+	reconnects   int
+}
+
+func newRecordingMetrics() *recordingMetrics {
+	return &recordingMetrics{
+		operations:   map[string]int{},
+		failures:     map[string]int{},
+		written:      map[string]int{},
+		read:         map[string]int{},
+		lag:          map[string]int64{},
+		acknowledged: map[string]int{},
+	}
+}
+
+func (m *recordingMetrics) ObserveRequest(operation string, duration time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.operations[operation]++
+	if err != nil {
+		m.failures[operation]++
+	}
+}
+
+func (m *recordingMetrics) EventsWritten(stream string, count int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.written[stream] += count
+}
+
+func (m *recordingMetrics) EventsRead(stream string, count int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.read[stream] += count
+}
+
+func (m *recordingMetrics) SubscriptionLag(stream string, lag int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lag[stream] = lag
+}
+
+func (m *recordingMetrics) Acked(stream, subscriptionName string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.acknowledged["ack"]++
+}
+
+func (m *recordingMetrics) Nacked(stream, subscriptionName string, action goro.Action) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.acknowledged[string(action)]++
+}
+
+func (m *recordingMetrics) Reconnected() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reconnects++
+}
+
+func (m *recordingMetrics) subscriptionLag(stream string) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lag[stream]
+}
+
+func TestMetrics(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("it should count the events written and read", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+		metrics := newRecordingMetrics()
+		client := s.Connect(goro.WithMetrics(metrics))
+
+		assert.Nil(t, client.Writer("tests").Write(ctx, goro.ExpectedVersionNone, testEvents(15)...))
+		err := client.Writer("tests").Write(ctx, goro.ExpectedVersionNone, testEvents(1)...)
+		assert.True(t, errors.Is(err, goro.ErrWrongExpectedVersion), "%v", err)
+
+		_, err = client.FowardsReader("tests").Read(ctx, 0, 20)
+		assert.Nil(t, err)
+
+		assert.Equal(t, map[string]int{goro.OperationWrite: 2, goro.OperationRead: 2}, metrics.operations)
+		assert.Equal(t, map[string]int{goro.OperationWrite: 1}, metrics.failures)
+		assert.Equal(t, map[string]int{"tests": 15}, metrics.written)
+		assert.Equal(t, map[string]int{"tests": 15}, metrics.read)
+	})
+
+	t.Run("it should record how far catchup subscriptions are behind", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+		metrics := newRecordingMetrics()
+		client := s.Connect(goro.WithMetrics(metrics))
+
+		assert.Nil(t, client.Writer("tests").Write(ctx, goro.ExpectedVersionNone, testEvents(5)...))
+
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		// the subscriptions of a Client decompress Events ahead of the consumer, which lowers the lag by one
+		messages := goro.NewCatchupSubscription(client, "tests", 0).Subscribe(ctx)
+
+		message := <-messages
+		assert.Nil(t, message.Error)
+		assert.Eventually(t, func() bool {
+			return metrics.subscriptionLag("tests") == 4
+		}, time.Second, 10*time.Millisecond)
+
+		for i := 1; i < 5; i++ {
+			message = <-messages
+			assert.Nil(t, message.Error)
+		}
+		assert.Eventually(t, func() bool {
+			return metrics.subscriptionLag("tests") == 0
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("it should count acks and nacks by action", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+		metrics := newRecordingMetrics()
+		client := s.Connect(goro.WithMetrics(metrics))
+
+		assert.Nil(t, client.Writer("tests").Write(ctx, goro.ExpectedVersionNone, testEvents(3)...))
+		subscription, err := client.PersistentSubscription("tests", "group", goro.PersistentSubscriptionSettings{})
+		assert.Nil(t, err)
+
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		messages := subscription.Subscribe(ctx)
+
+		for i, action := range []goro.Action{"", goro.ActionPark, goro.ActionSkip} {
+			message := <-messages
+			assert.Nil(t, message.Error)
+			if i == 0 {
+				assert.Nil(t, message.Ack())
+			} else {
+				assert.Nil(t, message.Nack(action))
+			}
+		}
+
+		metrics.mu.Lock()
+		defer metrics.mu.Unlock()
+		assert.Equal(t, map[string]int{"ack": 1, "park": 1, "skip": 1}, metrics.acknowledged)
+		assert.Equal(t, 1, metrics.operations[goro.OperationAck])
+		assert.Equal(t, 2, metrics.operations[goro.OperationNack])
+		assert.NotZero(t, metrics.operations[goro.OperationSubscriptionPoll])
+	})
+
+	t.Run("it should count reconnects to another node", func(t *testing.T) {
+		c := newFakeCluster(t, "Follower", "Leader")
+		metrics := newRecordingMetrics()
+		client := goro.Connect(c.nodes[0].URL, goro.WithGossipSeeds(c.host(1)), goro.WithMetrics(metrics))
+		assert.Nil(t, client.Writer("tests").Write(ctx, goro.ExpectedVersionAny, goro.CreateEvent("test", []byte("{}"), nil, 0)))
+		assert.Equal(t, 0, metrics.reconnects)
+
+		c.setState(0, "Leader")
+		c.setState(1, "Dead")
+		c.nodes[1].Close()
+
+		assert.Nil(t, client.Writer("tests").Write(ctx, goro.ExpectedVersionAny, goro.CreateEvent("test", []byte("{}"), nil, 0)))
+		assert.Equal(t, 1, metrics.reconnects)
+	})
+
+	t.Run("it should reject a nil collector", func(t *testing.T) {
+		_, err := goro.NewClient("http://localhost:2113", goro.WithMetrics(nil))
+		assert.True(t, errors.Is(err, goro.ErrInvalidOption), "%v", err)
+	})
+}
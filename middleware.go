@@ -0,0 +1,46 @@
+package goro
+
+import (
+	"net/http"
+)
+
+// Middleware wraps the http.RoundTripper that sends the requests of a Client, to inspect or change
+// requests and responses, for logging, authentication, header injection or metrics. Like any
+// http.RoundTripper, a Middleware must not modify the request it's given, but a clone of it.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc is a function that implements the http.RoundTripper interface
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip implements the http.RoundTripper interface
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// WithMiddleware adds Middleware to the Client. The first Middleware added sees requests first. It runs
+// for every request sent to Event Store by the readers, writers, subscriptions and management calls of
+// the Client, including retries, redirects and cluster discovery.
+func WithMiddleware(middleware ...Middleware) ClientOption {
+	return func(c *Client) {
+		c.middleware = append(c.middleware, middleware...)
+	}
+}
+
+// withMiddleware copies an http.Client to send its requests through middleware
+func withMiddleware(httpClient *http.Client, middleware []Middleware) *http.Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	transport := httpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		transport = middleware[i](transport)
+	}
+
+	copied := *httpClient
+	copied.Transport = transport
+	return &copied
+}
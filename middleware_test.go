@@ -0,0 +1,100 @@
+package goro_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vectorhacker/goro"
+	"github.com/vectorhacker/goro/estest"
+)
+
+func TestMiddleware(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("it should run for every call", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+
+		var mu sync.Mutex
+		calls := []string{}
+		record := func(next http.RoundTripper) http.RoundTripper {
+			return goro.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				mu.Lock()
+				calls = append(calls, req.Method+" "+req.URL.Path)
+				mu.Unlock()
+				return next.RoundTrip(req)
+			})
+		}
+		client := s.Connect(goro.WithMiddleware(record))
+
+		assert.Nil(t, client.Writer("tests").Write(ctx, goro.ExpectedVersionNone, goro.CreateEvent("test", []byte("{}"), nil, 0)))
+		_, err := client.FowardsReader("tests").Read(ctx, 0, 1)
+		assert.Nil(t, err)
+		assert.Nil(t, client.SetStreamMetadata(ctx, "tests", goro.StreamMetadata{MaxCount: 10}))
+
+		subscription, err := client.PersistentSubscription("tests", "group", goro.PersistentSubscriptionSettings{})
+		assert.Nil(t, err)
+		subscriptionCtx, cancel := context.WithCancel(ctx)
+		message := <-subscription.Subscribe(subscriptionCtx)
+		assert.Nil(t, message.Error)
+		assert.Nil(t, message.Ack())
+		cancel()
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Subset(t, calls, []string{
+			"POST /streams/tests",
+			"GET /streams/tests/0/forward/1",
+			"POST /streams/tests/metadata",
+			"PUT /subscriptions/tests/group",
+			"GET /subscriptions/tests/group/10",
+			"POST /subscriptions/tests/group/ack/" + message.Event.ID.String(),
+		})
+	})
+
+	t.Run("it should run in the order it was added", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+
+		order := []string{}
+		named := func(name string) goro.Middleware {
+			return func(next http.RoundTripper) http.RoundTripper {
+				return goro.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+					order = append(order, name)
+					return next.RoundTrip(req)
+				})
+			}
+		}
+		client := s.Connect(goro.WithMiddleware(named("first"), named("second")), goro.WithMiddleware(named("third")))
+
+		client.FowardsReader("tests").Read(ctx, 0, 1)
+		assert.Equal(t, []string{"first", "second", "third"}, order)
+	})
+
+	t.Run("it should inject headers", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+
+		token := func(next http.RoundTripper) http.RoundTripper {
+			return goro.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				req = req.Clone(req.Context())
+				req.Header.Set("Authorization", "Bearer token")
+				return next.RoundTrip(req)
+			})
+		}
+		seen := ""
+		inspect := func(next http.RoundTripper) http.RoundTripper {
+			return goro.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				seen = req.Header.Get("Authorization")
+				return next.RoundTrip(req)
+			})
+		}
+		client := s.Connect(goro.WithMiddleware(token, inspect))
+
+		client.FowardsReader("tests").Read(ctx, 0, 1)
+		assert.Equal(t, "Bearer token", seen)
+	})
+}
@@ -3,6 +3,10 @@ package goro
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
 )
 
 type direction string
@@ -12,18 +16,86 @@ const (
 	directionBackwards           = "backward"
 )
 
+// StreamHead can be used as the start of a backwards Read to begin at the last Event of a stream
+const StreamHead int64 = -1
+
+// feedEntry is an Event embedded in an atom feed
+type feedEntry struct {
+	Event
+	IsJSON *bool `json:"isJson"`
+}
+
+// feed is a page of an atom feed
+type feed struct {
+	ETag    string      `json:"eTag"`
+	Entries []feedEntry `json:"entries"`
+}
+
+func (f feed) events() Events {
+	events := make(Events, len(f.Entries))
+	for i, entry := range f.Entries {
+		events[i] = entry.Event
+		events[i].Binary = entry.IsJSON != nil && !*entry.IsJSON
+	}
+
+	return events
+}
+
+// byPosition sorts Events by their position in the stream they were read from, which differs from their
+// version when they are read through links, like in the $ce- category streams.
+type byPosition Events
+
+func (e byPosition) Len() int {
+	return len(e)
+}
+
+func (e byPosition) Swap(a, b int) {
+	e[b], e[a] = e[a], e[b]
+}
+
+func (e byPosition) Less(a, b int) bool {
+	return position(e[a]) < position(e[b])
+}
+
+func position(event Event) int64 {
+	if event.PositionStream != "" {
+		return event.Position
+	}
+
+	return event.Version
+}
+
+// read returns the Events of the feed in the order of the stream, with the data of Binary Events fetched.
+// Feeds list their newest entries first.
+func (f feed) read(ctx context.Context, slinger Slinger) (Events, error) {
+	events := f.events()
+	sort.Stable(byPosition(events))
+	if err := resolveBinaryData(ctx, slinger, events); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
 type streamReader struct {
 	stream    string
 	direction direction
 	slinger   Slinger
+	tracer    Tracer
+	metrics   MetricsCollector
+	logger    Logger
 }
 
-// NewBackwardsReader creates a Reader that reads events backwards
+// NewBackwardsReader creates a Reader that reads events backwards. Reads begin at the event numbered
+// start+1, or at the last event for StreamHead.
 func NewBackwardsReader(slinger Slinger, stream string) Reader {
 	return &streamReader{
 		stream:    stream,
 		direction: directionBackwards,
 		slinger:   slinger,
+		tracer:    tracerOf(slinger),
+		metrics:   metricsOf(slinger),
+		logger:    loggerOf(slinger),
 	}
 }
 
@@ -33,54 +105,101 @@ func NewForwardsReader(slinger Slinger, stream string) Reader {
 		stream:    stream,
 		direction: directionForwards,
 		slinger:   slinger,
+		tracer:    tracerOf(slinger),
+		metrics:   metricsOf(slinger),
+		logger:    loggerOf(slinger),
 	}
 }
 
 func (r streamReader) Read(ctx context.Context, start int64, count int) (Events, error) {
 	events := Events{}
-	response := struct {
-		Events Events `json:"entries"`
-	}{}
 
 	next := start
 	if r.direction == directionBackwards {
 		next++
 	}
+	head := r.direction == directionBackwards && start == StreamHead
 
-	for len(events) != count {
-		path := fmt.Sprintf("/streams/%s/%d/%s/10", r.stream, next, r.direction)
-		req, err := r.slinger.
-			Sling().
-			Get(path).
-			Set("Accept", "application/vnd.eventstore.events+json").
-			QueryStruct(embedParams{
-				Embed: "body",
-			}).
-			Request()
-		if err != nil {
-			return nil, err
+	for len(events) < count {
+		pageSize := readCount
+		if remaining := count - len(events); remaining < pageSize {
+			pageSize = remaining
 		}
 
-		req = req.WithContext(ctx)
+		from := strconv.FormatInt(next, 10)
+		if head {
+			from = "head"
+		}
 
-		res, err := r.slinger.Sling().Do(req, &response, nil)
+		path := fmt.Sprintf("/streams/%s/%s/%s/%d", r.stream, from, r.direction, pageSize)
+		page, err := r.readPage(ctx, path)
 		if err != nil {
 			return nil, err
 		}
 
-		err = relevantError(res.StatusCode)
-		if err != nil {
-			return nil, err
+		if r.direction == directionBackwards {
+			sort.Stable(sort.Reverse(byPosition(page)))
 		}
 
-		events = append(events, response.Events...)
+		events = append(events, page...)
 
 		switch r.direction {
 		case directionBackwards:
-			next -= int64(len(events))
+			next -= int64(len(page))
+			if head && len(page) > 0 {
+				next = position(page[len(page)-1]) - 1
+				head = false
+			}
 		case directionForwards:
-			next += int64(len(events))
+			next += int64(len(page))
+		}
+
+		// a short page means we've reached the end of the stream
+		if len(page) < pageSize {
+			break
 		}
 	}
 	return events, nil
 }
+
+// readPage reads a page of a stream in the order of the stream
+func (r streamReader) readPage(ctx context.Context, path string) (events Events, err error) {
+	ctx, span := r.tracer.Start(ctx, SpanRead)
+	span.SetAttribute(AttributeStream, r.stream)
+	start := time.Now()
+	var res *http.Response
+	defer func() {
+		span.SetAttribute(AttributeEventCount, len(events))
+		endSpan(span, res, err)
+		r.metrics.ObserveRequest(OperationRead, time.Since(start), err)
+		if err == nil {
+			r.metrics.EventsRead(r.stream, len(events))
+			r.logger.DebugContext(ctx, "fetched page", "stream", r.stream, "path", path, "events", len(events))
+		}
+	}()
+
+	req, err := r.slinger.
+		Sling().
+		Get(path).
+		Set("Accept", "application/vnd.eventstore.events+json").
+		QueryStruct(embedParams{
+			Embed: "body",
+		}).
+		Request()
+	if err != nil {
+		return nil, err
+	}
+
+	response := feed{}
+	res, err = r.slinger.Sling().Do(req.WithContext(ctx), &response, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = relevantError(res)
+	if err != nil {
+		return nil, err
+	}
+
+	return response.read(ctx, r.slinger)
+}
@@ -86,4 +86,37 @@ func TestReader(t *testing.T) {
 		assert.Nil(t, err)
 		assert.Len(t, events, 20)
 	})
+
+	t.Run("it should read binary events as raw bytes", func(t *testing.T) {
+		mux := pat.New()
+		mux.Get("/streams/{stream}/{start}/{direction}/{pageSize}", func(w http.ResponseWriter, r *http.Request) {
+			err := json.NewEncoder(w).Encode(map[string]interface{}{
+				"entries": []map[string]interface{}{
+					{"eventId": goro.NewUUID(), "eventType": "deposit", "streamId": "test", "eventNumber": 0, "isJson": true, "data": map[string]string{"double": "trouble"}},
+					{"eventId": goro.NewUUID(), "eventType": "binary", "streamId": "test", "eventNumber": 1, "isJson": false, "data": "\u0001\u0002"},
+				},
+			})
+			assert.Nil(t, err)
+		})
+		mux.Get("/streams/{stream}/{version}", func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "test", r.URL.Query().Get(":stream"))
+			assert.Equal(t, "1", r.URL.Query().Get(":version"))
+			assert.Equal(t, "application/octet-stream", r.Header.Get("Accept"))
+
+			w.Write([]byte{0x01, 0x02})
+		})
+		s := httptest.NewServer(mux)
+		defer s.Close()
+
+		r := goro.NewForwardsReader(goro.SlingerFunc(func() *sling.Sling {
+			return sling.New().Base(s.URL).Client(s.Client()).New()
+		}), "test")
+
+		events, err := r.Read(context.Background(), 0, 10)
+		assert.Nil(t, err)
+		assert.Len(t, events, 2)
+		assert.False(t, events[0].Binary)
+		assert.True(t, events[1].Binary)
+		assert.Equal(t, []byte{0x01, 0x02}, []byte(events[1].Data))
+	})
 }
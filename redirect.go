@@ -0,0 +1,120 @@
+package goro
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/dghubble/sling"
+)
+
+const maxRedirects = 10
+
+// WithRequiresMaster sets the ES-RequiresMaster header on every request, so that followers redirect
+// reads to the leader instead of serving them, possibly behind the latest writes
+func WithRequiresMaster() ClientOption {
+	return func(c *Client) {
+		c.sling.Set("ES-RequiresMaster", "True")
+	}
+}
+
+// WithFollowRedirects sets whether the Client follows the redirects followers reply with to reach the
+// leader. Redirects are followed by default, keeping credentials only for the host of the Client, its
+// gossip seeds and the nodes they gossip about. When they aren't followed, requests that reach a follower
+// fail with ErrNotLeader.
+func WithFollowRedirects(follow bool) ClientOption {
+	return func(c *Client) {
+		c.noRedirects = !follow
+	}
+}
+
+// withoutRedirects copies an http.Client so that it returns redirects instead of following them
+func withoutRedirects(httpClient *http.Client) *http.Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	copied := *httpClient
+	copied.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &copied
+}
+
+func isRedirect(statusCode int) bool {
+	switch statusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	default:
+		return false
+	}
+}
+
+// knownHosts are the hosts of the nodes of a cluster: the host the Client connects to, the gossip seeds
+// and the members they gossip about
+type knownHosts struct {
+	mu    sync.RWMutex
+	hosts map[string]bool
+}
+
+func newKnownHosts(hosts ...string) *knownHosts {
+	k := &knownHosts{hosts: map[string]bool{}}
+	k.add(hosts...)
+	return k
+}
+
+func (k *knownHosts) add(hosts ...string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	for _, host := range hosts {
+		k.hosts[host] = true
+	}
+}
+
+func (k *knownHosts) has(host string) bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return k.hosts[host]
+}
+
+// redirectDoer is a sling.Doer that follows redirects with the same method, headers and body. Unlike
+// http.Client, it keeps the Authorization header when the leader is another known node of the cluster.
+// Redirects to other hosts are followed without credentials, and redirects that change the scheme, like
+// https to http, aren't followed.
+type redirectDoer struct {
+	doer  sling.Doer
+	known *knownHosts
+}
+
+// Do implements the sling.Doer interface
+func (d redirectDoer) Do(req *http.Request) (*http.Response, error) {
+	for redirects := 0; ; redirects++ {
+		res, err := d.doer.Do(req)
+		if err != nil || !isRedirect(res.StatusCode) {
+			return res, err
+		}
+
+		location, err := res.Location()
+		if err != nil || redirects == maxRedirects || (req.Body != nil && req.GetBody == nil) ||
+			location.Scheme != req.URL.Scheme {
+			// the redirect can't be followed, and fails with ErrNotLeader
+			return res, nil
+		}
+		res.Body.Close()
+
+		next := req.Clone(req.Context())
+		next.URL = location
+		next.Host = ""
+		if location.Host != req.URL.Host && (d.known == nil || !d.known.has(location.Host)) {
+			next.Header.Del("Authorization")
+		}
+		if req.GetBody != nil {
+			next.Body, err = req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+		}
+		req = next
+	}
+}
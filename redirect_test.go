@@ -0,0 +1,119 @@
+package goro_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vectorhacker/goro"
+)
+
+// redirectedWrite is a write that reached the leader
+type redirectedWrite struct {
+	body          string
+	authorization string
+}
+
+func TestRedirects(t *testing.T) {
+	ctx := context.Background()
+	newNodes := func(t *testing.T, status int) (*httptest.Server, *httptest.Server, func() []redirectedWrite) {
+		mu := sync.Mutex{}
+		writes := []redirectedWrite{}
+		leader := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			writes = append(writes, redirectedWrite{body: string(body), authorization: r.Header.Get("Authorization")})
+			mu.Unlock()
+			w.WriteHeader(http.StatusCreated)
+		}))
+		t.Cleanup(leader.Close)
+
+		var follower *httptest.Server
+		follower = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/gossip" {
+				// the follower still believes it leads the cluster
+				u, _ := url.Parse(follower.URL)
+				port, _ := strconv.Atoi(u.Port())
+				json.NewEncoder(w).Encode(map[string]interface{}{"members": []map[string]interface{}{
+					{"state": "Leader", "isAlive": true, "externalHttpIp": u.Hostname(), "externalHttpPort": port},
+				}})
+				return
+			}
+
+			assert.Equal(t, "True", r.Header.Get("ES-RequiresMaster"))
+			http.Redirect(w, r, leader.URL+r.URL.Path, status)
+		}))
+		t.Cleanup(follower.Close)
+
+		return leader, follower, func() []redirectedWrite {
+			mu.Lock()
+			defer mu.Unlock()
+			return append([]redirectedWrite{}, writes...)
+		}
+	}
+
+	t.Run("it should follow redirects to the leader", func(t *testing.T) {
+		_, follower, writes := newNodes(t, http.StatusTemporaryRedirect)
+		client := goro.Connect(follower.URL, goro.WithRequiresMaster())
+
+		err := client.Writer("tests").Write(ctx, goro.ExpectedVersionAny, goro.CreateEvent("test", []byte("{\"a\":1}"), nil, 0))
+		assert.Nil(t, err)
+		assert.Len(t, writes(), 1)
+		assert.Contains(t, writes()[0].body, "{\"a\":1}")
+	})
+
+	t.Run("it should keep credentials for the nodes of the cluster", func(t *testing.T) {
+		leader, follower, writes := newNodes(t, http.StatusTemporaryRedirect)
+		client := goro.Connect(follower.URL, goro.WithBasicAuth("admin", "changeit"), goro.WithRequiresMaster(),
+			goro.WithGossipSeeds(strings.TrimPrefix(leader.URL, "http://")))
+
+		assert.Nil(t, client.Writer("tests").Write(ctx, goro.ExpectedVersionAny, goro.CreateEvent("test", []byte("{}"), nil, 0)))
+		assert.Len(t, writes(), 1)
+		assert.NotEmpty(t, writes()[0].authorization)
+	})
+
+	t.Run("it should drop credentials for other hosts", func(t *testing.T) {
+		_, follower, writes := newNodes(t, http.StatusTemporaryRedirect)
+		client := goro.Connect(follower.URL, goro.WithBasicAuth("admin", "changeit"), goro.WithRequiresMaster())
+
+		assert.Nil(t, client.Writer("tests").Write(ctx, goro.ExpectedVersionAny, goro.CreateEvent("test", []byte("{}"), nil, 0)))
+		assert.Len(t, writes(), 1)
+		assert.Empty(t, writes()[0].authorization)
+	})
+
+	t.Run("it should not follow redirects to another scheme", func(t *testing.T) {
+		leader := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Error("the redirect was followed")
+		}))
+		defer leader.Close()
+		follower := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, leader.URL+r.URL.Path, http.StatusTemporaryRedirect)
+		}))
+		defer follower.Close()
+		client := goro.Connect(follower.URL, goro.WithBasicAuth("admin", "changeit"))
+
+		err := client.Writer("tests").Write(ctx, goro.ExpectedVersionAny, goro.CreateEvent("test", []byte("{}"), nil, 0))
+		assert.True(t, errors.Is(err, goro.ErrNotLeader), "%v", err)
+	})
+
+	for _, status := range []int{http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect} {
+		status := status
+		t.Run("it should fail with ErrNotLeader on a "+strconv.Itoa(status)+" when redirects are disabled", func(t *testing.T) {
+			_, follower, writes := newNodes(t, status)
+			client := goro.Connect(follower.URL, goro.WithRequiresMaster(), goro.WithFollowRedirects(false))
+
+			err := client.Writer("tests").Write(ctx, goro.ExpectedVersionAny, goro.CreateEvent("test", []byte("{}"), nil, 0))
+			assert.True(t, errors.Is(err, goro.ErrNotLeader), "%v", err)
+			assert.Empty(t, writes())
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package goro
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+// EventRegistry maps Event types to the Go types their data is decoded into
+type EventRegistry struct {
+	mu    sync.RWMutex
+	types map[string]reflect.Type
+	names map[reflect.Type]string
+	codec Codec
+}
+
+// EventRegistryOption applies options to an EventRegistry
+type EventRegistryOption func(*EventRegistry)
+
+// WithCodec sets the Codec an EventRegistry encodes and decodes Event data with. By default values are
+// encoded as json.
+func WithCodec(codec Codec) EventRegistryOption {
+	return func(r *EventRegistry) {
+		r.codec = codec
+	}
+}
+
+// NewEventRegistry creates an empty EventRegistry
+func NewEventRegistry(options ...EventRegistryOption) *EventRegistry {
+	r := &EventRegistry{
+		types: map[string]reflect.Type{},
+		names: map[reflect.Type]string{},
+		codec: JSONCodec{},
+	}
+	for _, opt := range options {
+		opt(r)
+	}
+
+	return r
+}
+
+// Register associates an Event type with the Go type of v. Decode returns values of the same type as v,
+// so registering a pointer makes Decode return pointers. Encode accepts both values and pointers.
+func (r *EventRegistry) Register(eventType string, v interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := reflect.TypeOf(v)
+	r.types[eventType] = t
+	r.names[t] = eventType
+	if t.Kind() == reflect.Ptr {
+		r.names[t.Elem()] = eventType
+	} else {
+		r.names[reflect.PtrTo(t)] = eventType
+	}
+}
+
+// Encode creates a new Event from a value of a registered type. The Event is Binary unless the Codec
+// of the registry encodes json.
+func (r *EventRegistry) Encode(v interface{}) (Event, error) {
+	r.mu.RLock()
+	eventType, ok := r.names[reflect.TypeOf(v)]
+	r.mu.RUnlock()
+	if !ok {
+		return Event{}, ErrUnknownEventType
+	}
+
+	data, err := r.codec.Marshal(v)
+	if err != nil {
+		return Event{}, err
+	}
+
+	event := CreateEvent(eventType, data, nil, 0)
+	event.Binary = r.codec.ContentType() != jsonContentType
+	return event, nil
+}
+
+// Decode decodes the data of an Event into a new value of its registered type
+func (r *EventRegistry) Decode(event Event) (interface{}, error) {
+	r.mu.RLock()
+	t, ok := r.types[event.Type]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, ErrUnknownEventType
+	}
+
+	if t.Kind() == reflect.Ptr {
+		v := reflect.New(t.Elem())
+		if err := r.codec.Unmarshal(event.Data, v.Interface()); err != nil {
+			return nil, err
+		}
+
+		return v.Interface(), nil
+	}
+
+	v := reflect.New(t)
+	if err := r.codec.Unmarshal(event.Data, v.Interface()); err != nil {
+		return nil, err
+	}
+
+	return v.Elem().Interface(), nil
+}
+
+type decodingSubscriber struct {
+	registry   *EventRegistry
+	subscriber Subscriber
+}
+
+// NewDecodingSubscriber creates a Subscriber that decodes the Events of another Subscriber using a registry
+// and sets the decoded value on each StreamMessage. A message whose Event can't be decoded carries the
+// decoding error along with the Event and its Acknowledger, and the subscription continues.
+func NewDecodingSubscriber(registry *EventRegistry, subscriber Subscriber) Subscriber {
+	return &decodingSubscriber{
+		registry:   registry,
+		subscriber: subscriber,
+	}
+}
+
+func (s *decodingSubscriber) unwrap() Subscriber {
+	return s.subscriber
+}
+
+// Subscribe implements the Subscriber interface
+func (s *decodingSubscriber) Subscribe(ctx context.Context) <-chan StreamMessage {
+	stream := make(chan StreamMessage)
+
+	go func() {
+		defer close(stream)
+
+		for message := range s.subscriber.Subscribe(ctx) {
+			if message.Error == nil {
+				message.Value, message.Error = s.registry.Decode(message.Event)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case stream <- message:
+			}
+		}
+	}()
+
+	return stream
+}
@@ -0,0 +1,85 @@
+package goro_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vectorhacker/goro"
+)
+
+type deposited struct {
+	Amount int `json:"amount"`
+}
+
+type withdrawn struct {
+	Amount int `json:"amount"`
+}
+
+type subscriberFunc func(ctx context.Context) <-chan goro.StreamMessage
+
+func (f subscriberFunc) Subscribe(ctx context.Context) <-chan goro.StreamMessage {
+	return f(ctx)
+}
+
+func TestEventRegistry(t *testing.T) {
+	registry := goro.NewEventRegistry()
+	registry.Register("deposited", deposited{})
+	registry.Register("withdrawn", &withdrawn{})
+
+	t.Run("it should encode and decode values", func(t *testing.T) {
+		event, err := registry.Encode(deposited{Amount: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, "deposited", event.Type)
+		assert.JSONEq(t, "{\"amount\":10}", string(event.Data))
+
+		v, err := registry.Decode(event)
+		assert.Nil(t, err)
+		assert.Equal(t, deposited{Amount: 10}, v)
+	})
+
+	t.Run("it should encode and decode pointers", func(t *testing.T) {
+		event, err := registry.Encode(&deposited{Amount: 5})
+		assert.Nil(t, err)
+		assert.Equal(t, "deposited", event.Type)
+
+		event, err = registry.Encode(withdrawn{Amount: 5})
+		assert.Nil(t, err)
+		assert.Equal(t, "withdrawn", event.Type)
+
+		v, err := registry.Decode(event)
+		assert.Nil(t, err)
+		assert.Equal(t, &withdrawn{Amount: 5}, v)
+	})
+
+	t.Run("it should fail on unknown types", func(t *testing.T) {
+		_, err := registry.Encode(struct{}{})
+		assert.Equal(t, goro.ErrUnknownEventType, err)
+
+		_, err = registry.Decode(goro.Event{Type: "unknown"})
+		assert.Equal(t, goro.ErrUnknownEventType, err)
+	})
+
+	t.Run("it should decode the messages of a Subscriber", func(t *testing.T) {
+		event, err := registry.Encode(deposited{Amount: 10})
+		assert.Nil(t, err)
+
+		subscriber := goro.NewDecodingSubscriber(registry, subscriberFunc(func(ctx context.Context) <-chan goro.StreamMessage {
+			stream := make(chan goro.StreamMessage, 2)
+			stream <- goro.StreamMessage{Event: event}
+			stream <- goro.StreamMessage{Event: goro.Event{Type: "unknown"}}
+			close(stream)
+			return stream
+		}))
+
+		messages := []goro.StreamMessage{}
+		for message := range subscriber.Subscribe(context.Background()) {
+			messages = append(messages, message)
+		}
+
+		assert.Len(t, messages, 2)
+		assert.Nil(t, messages[0].Error)
+		assert.Equal(t, deposited{Amount: 10}, messages[0].Value)
+		assert.Equal(t, goro.ErrUnknownEventType, messages[1].Error)
+	})
+}
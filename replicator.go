@@ -0,0 +1,192 @@
+package goro
+
+import (
+	"context"
+	"time"
+)
+
+// CatchupSubscriber creates catchup subscriptions. It is implemented by Client.
+type CatchupSubscriber interface {
+	CatchupSubscription(stream string, start int64) Subscriber
+}
+
+// Replicator copies the Events of a stream from one Event Store to another, for example to migrate to a
+// new cluster while the old one is still in use. A Replicator that restarts before it saved its
+// Checkpoint replicates the last Events again. The Events keep their ids and are written with
+// ExpectedVersionAny, so Event Store ignores the ones it recently wrote, but it only remembers the ids in
+// a cache that doesn't survive a restart or a scavenge of the target, which can then hold duplicates.
+type Replicator struct {
+	source     CatchupSubscriber
+	target     Streams
+	stream     string
+	streamName StreamNamer
+	filter     func(Event) bool
+	transform  EventMapper
+	checkpoint Checkpoint
+	every      int
+	interval   time.Duration
+	logger     Logger
+}
+
+// ReplicatorOption applies options to a Replicator
+type ReplicatorOption func(*Replicator)
+
+// WithStreamMapping sets the name of the target stream of each Event from the name of its source stream.
+// By default Events are written to streams with the same name.
+func WithStreamMapping(namer StreamNamer) ReplicatorOption {
+	return func(r *Replicator) {
+		r.streamName = namer
+	}
+}
+
+// WithFilter only replicates the Events for which filter returns true
+func WithFilter(filter func(Event) bool) ReplicatorOption {
+	return func(r *Replicator) {
+		r.filter = filter
+	}
+}
+
+// WithTransform maps the Events before they are written to the target
+func WithTransform(transform EventMapper) ReplicatorOption {
+	return func(r *Replicator) {
+		r.transform = transform
+	}
+}
+
+// WithCheckpoint sets where a Replicator saves its position in the source stream. By default the
+// position is kept in memory, and a new Replicator starts at the beginning of the stream.
+func WithCheckpoint(checkpoint Checkpoint) ReplicatorOption {
+	return func(r *Replicator) {
+		r.checkpoint = checkpoint
+	}
+}
+
+// WithCheckpointEvery saves the Checkpoint after events Events were replicated, or interval after the
+// first Event that wasn't saved, whichever comes first. Zero disables either, and disabling both saves
+// the Checkpoint after every Event. By default the Checkpoint
+// is saved every 100 Events or every second. Saving less often means more Events are replicated again
+// after a restart, see Replicator about duplicates.
+func WithCheckpointEvery(events int, interval time.Duration) ReplicatorOption {
+	return func(r *Replicator) {
+		r.every = events
+		r.interval = interval
+	}
+}
+
+// NewReplicator creates a Replicator of a stream. The stream can be a category, in which case each Event
+// is written to a stream named after the stream it was originally written to. The $all stream isn't
+// supported, since catchup subscriptions page it by event number rather than by its positions.
+// When source is a Client created with WithLogger, the Replicator logs its checkpoint saves.
+func NewReplicator(source CatchupSubscriber, target Streams, stream string, options ...ReplicatorOption) *Replicator {
+	r := &Replicator{
+		source: source,
+		target: target,
+		stream: stream,
+		streamName: func(stream string) string {
+			return stream
+		},
+		filter: func(Event) bool {
+			return true
+		},
+		transform: func(event Event) (Event, error) {
+			return event, nil
+		},
+		checkpoint: NewMemoryCheckpoint(),
+		every:      100,
+		interval:   time.Second,
+		logger:     loggerOf(source),
+	}
+	for _, opt := range options {
+		opt(r)
+	}
+
+	return r
+}
+
+// Run replicates Events until ctx is done or replication fails. It resumes after the position saved in
+// the Checkpoint, and saves the position of the last replicated Event before it returns.
+func (r *Replicator) Run(ctx context.Context) error {
+	last, err := r.checkpoint.Load(ctx)
+	if err != nil {
+		return err
+	}
+	saved := last
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// the pending Events are saved even when ctx is done
+	defer func() {
+		if last != saved {
+			r.save(context.WithoutCancel(ctx), last)
+		}
+	}()
+
+	var timer <-chan time.Time
+	pending := 0
+	messages := r.source.CatchupSubscription(r.stream, last+1).Subscribe(ctx)
+	for {
+		select {
+		case message, ok := <-messages:
+			if !ok {
+				return ctx.Err()
+			}
+			if message.Error != nil {
+				return message.Error
+			}
+
+			if err := r.replicate(ctx, message.Event); err != nil {
+				return err
+			}
+			last = position(message.Event)
+			pending++
+
+			if (r.every > 0 || r.interval > 0) && (r.every <= 0 || pending < r.every) {
+				if timer == nil && r.interval > 0 {
+					timer = time.After(r.interval)
+				}
+				continue
+			}
+		case <-timer:
+		}
+
+		if err := r.save(ctx, last); err != nil {
+			return err
+		}
+		saved, pending, timer = last, 0, nil
+	}
+}
+
+// save saves the position of the last replicated Event in the Checkpoint
+func (r *Replicator) save(ctx context.Context, position int64) error {
+	if err := r.checkpoint.Save(ctx, position); err != nil {
+		r.logger.ErrorContext(ctx, "saving the checkpoint failed", "stream", r.stream, "position", position, "error", err)
+		return err
+	}
+	r.logger.DebugContext(ctx, "saved checkpoint", "stream", r.stream, "position", position)
+
+	return nil
+}
+
+// replicate writes a copy of an Event to the target
+func (r *Replicator) replicate(ctx context.Context, event Event) error {
+	if !r.filter(event) {
+		return nil
+	}
+
+	event, err := r.transform(event)
+	if err != nil {
+		return err
+	}
+
+	replica := Event{
+		ID:       event.ID,
+		Type:     event.Type,
+		Data:     event.Data,
+		Metadata: event.Metadata,
+		Binary:   event.Binary,
+		At:       event.At,
+	}
+
+	return r.target.Writer(r.streamName(event.Stream)).Write(ctx, ExpectedVersionAny, replica)
+}
@@ -0,0 +1,150 @@
+package goro_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vectorhacker/goro"
+	"github.com/vectorhacker/goro/memory"
+)
+
+func TestReplicator(t *testing.T) {
+	ctx := context.Background()
+	deposit := func(amount string) goro.Event {
+		return goro.CreateEvent("deposit", []byte("{\"amount\":"+amount+"}"), []byte("{\"by\":\"me\"}"), 0)
+	}
+	readAll := func(store *memory.Store, stream string) goro.Events {
+		events, err := store.FowardsReader(stream).Read(ctx, 0, 100)
+		if err == goro.ErrStreamNotFound {
+			return goro.Events{}
+		}
+		assert.Nil(t, err)
+		return events
+	}
+	run := func(r *goro.Replicator, until func() bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		done := make(chan error)
+		go func() {
+			done <- r.Run(ctx)
+		}()
+
+		assert.Eventually(t, until, time.Second, 5*time.Millisecond)
+		cancel()
+		assert.Equal(t, context.Canceled, <-done)
+	}
+
+	t.Run("it should replicate a category with a mapping, filter and transform", func(t *testing.T) {
+		source, target := memory.NewStore(), memory.NewStore()
+		assert.Nil(t, source.Writer("account-1").Write(ctx, goro.ExpectedVersionNone, deposit("1"), goro.CreateEvent("audit", []byte("{}"), nil, 1)))
+		assert.Nil(t, source.Writer("account-2").Write(ctx, goro.ExpectedVersionNone, deposit("2")))
+
+		r := goro.NewReplicator(source, target, "$ce-account",
+			goro.WithStreamMapping(func(stream string) string {
+				return "copy-" + stream
+			}),
+			goro.WithFilter(func(event goro.Event) bool {
+				return event.Type != "audit"
+			}),
+			goro.WithTransform(func(event goro.Event) (goro.Event, error) {
+				event.Type = "deposited"
+				return event, nil
+			}),
+		)
+		run(r, func() bool {
+			return len(readAll(target, "copy-account-2")) == 1
+		})
+
+		expected := readAll(source, "account-1")
+		events := readAll(target, "copy-account-1")
+		assert.Len(t, events, 1)
+		assert.Equal(t, expected[0].ID, events[0].ID)
+		assert.Equal(t, "deposited", events[0].Type)
+		assert.Equal(t, []byte(expected[0].Data), []byte(events[0].Data))
+		assert.Equal(t, []byte(expected[0].Metadata), []byte(events[0].Metadata))
+	})
+
+	t.Run("it should resume from its checkpoint", func(t *testing.T) {
+		source, target := memory.NewStore(), memory.NewStore()
+		checkpoint := goro.NewStreamCheckpoint(target, "replicator-checkpoint")
+		assert.Nil(t, source.Writer("account-1").Write(ctx, goro.ExpectedVersionNone, deposit("1"), deposit("2")))
+
+		run(goro.NewReplicator(source, target, "account-1", goro.WithCheckpoint(checkpoint), goro.WithCheckpointEvery(1, 0)), func() bool {
+			position, _ := checkpoint.Load(ctx)
+			return position == 1
+		})
+
+		assert.Nil(t, source.Writer("account-1").Write(ctx, 1, deposit("3")))
+		run(goro.NewReplicator(source, target, "account-1", goro.WithCheckpoint(checkpoint), goro.WithCheckpointEvery(1, 0)), func() bool {
+			return len(readAll(target, "account-1")) == 3
+		})
+
+		position, err := checkpoint.Load(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), position)
+		assert.Len(t, readAll(target, "replicator-checkpoint"), 1)
+	})
+
+	t.Run("it should not duplicate events replicated before a crash", func(t *testing.T) {
+		source, target := memory.NewStore(), memory.NewStore()
+		assert.Nil(t, source.Writer("account-1").Write(ctx, goro.ExpectedVersionNone, deposit("1"), deposit("2")))
+
+		// both replicators start without a checkpoint
+		run(goro.NewReplicator(source, target, "account-1"), func() bool {
+			return len(readAll(target, "account-1")) == 2
+		})
+		checkpoint := goro.NewMemoryCheckpoint()
+		run(goro.NewReplicator(source, target, "account-1", goro.WithCheckpoint(checkpoint), goro.WithCheckpointEvery(1, 0)), func() bool {
+			position, _ := checkpoint.Load(ctx)
+			return position == 1
+		})
+
+		assert.Len(t, readAll(target, "account-1"), 2)
+	})
+
+	t.Run("it should save its checkpoint every few events and when it stops", func(t *testing.T) {
+		source, target := memory.NewStore(), memory.NewStore()
+		checkpoint := &savingCheckpoint{Checkpoint: goro.NewMemoryCheckpoint()}
+		assert.Nil(t, source.Writer("account-1").Write(ctx, goro.ExpectedVersionNone, deposit("1"), deposit("2"), deposit("3"), deposit("4"), deposit("5")))
+
+		run(goro.NewReplicator(source, target, "account-1", goro.WithCheckpoint(checkpoint), goro.WithCheckpointEvery(2, 0)), func() bool {
+			return len(readAll(target, "account-1")) == 5
+		})
+
+		assert.Equal(t, []int64{1, 3, 4}, checkpoint.positions())
+	})
+
+	t.Run("it should save its checkpoint on an interval", func(t *testing.T) {
+		source, target := memory.NewStore(), memory.NewStore()
+		checkpoint := &savingCheckpoint{Checkpoint: goro.NewMemoryCheckpoint()}
+		assert.Nil(t, source.Writer("account-1").Write(ctx, goro.ExpectedVersionNone, deposit("1"), deposit("2"), deposit("3")))
+
+		run(goro.NewReplicator(source, target, "account-1", goro.WithCheckpoint(checkpoint), goro.WithCheckpointEvery(100, 10*time.Millisecond)), func() bool {
+			return len(checkpoint.positions()) > 0
+		})
+
+		assert.Equal(t, []int64{2}, checkpoint.positions())
+	})
+}
+
+// savingCheckpoint records the positions saved in a Checkpoint
+type savingCheckpoint struct {
+	goro.Checkpoint
+	mu    sync.Mutex
+	saves []int64
+}
+
+func (c *savingCheckpoint) Save(ctx context.Context, position int64) error {
+	c.mu.Lock()
+	c.saves = append(c.saves, position)
+	c.mu.Unlock()
+	return c.Checkpoint.Save(ctx, position)
+}
+
+func (c *savingCheckpoint) positions() []int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]int64{}, c.saves...)
+}
@@ -0,0 +1,221 @@
+package goro
+
+import (
+	"context"
+	"errors"
+)
+
+const (
+	loadCount         = 100 // 100 events per read while replaying
+	defaultMaxRetries = 3
+)
+
+// Aggregate is an event sourced domain object. Its state is rebuilt by applying the Events of its stream
+// in order.
+type Aggregate interface {
+	// Apply mutates the state of the Aggregate using an Event read from its stream
+	Apply(event Event)
+	// Changes returns the new Events that have not been written to the stream yet
+	Changes() Events
+	// Version returns the version of the last Event applied from the stream, or ExpectedVersionNone if
+	// the stream has no Events yet
+	Version() int64
+}
+
+// AggregateFactory creates a new, empty Aggregate for an id
+type AggregateFactory func(id string) Aggregate
+
+// StreamNamer maps an Aggregate id to the name of its stream
+type StreamNamer func(id string) string
+
+// CategoryStreamNamer names streams using the Event Store category convention, "{category}-{id}", so
+// that every Aggregate of a kind can be read from the $ce-{category} projection.
+func CategoryStreamNamer(category string) StreamNamer {
+	return func(id string) string {
+		return category + "-" + id
+	}
+}
+
+// Streams creates Readers and Writers for streams, and manages their metadata. It is implemented by
+// Client, and can be implemented by test doubles.
+type Streams interface {
+	Writer(stream string) Writer
+	FowardsReader(stream string) Reader
+	BackwardsReader(stream string) Reader
+	SetStreamMetadata(ctx context.Context, stream string, metadata StreamMetadata) error
+}
+
+// Repository loads and saves Aggregates by replaying and appending to their streams
+type Repository struct {
+	factory         AggregateFactory
+	streamName      StreamNamer
+	maxRetries      int
+	snapshotPolicy  SnapshotPolicy
+	forwardsReader  func(stream string) Reader
+	backwardsReader func(stream string) Reader
+	writer          func(stream string) Writer
+	setMetadata     func(ctx context.Context, stream string, metadata StreamMetadata) error
+	// readMetadata is nil when the Streams of the Repository can't read metadata
+	readMetadata func(ctx context.Context, stream string) (StreamMetadata, error)
+}
+
+// metadataReader reads the metadata of streams, like Client and memory.Store
+type metadataReader interface {
+	StreamMetadata(ctx context.Context, stream string) (StreamMetadata, error)
+}
+
+// RepositoryOption applies options to a Repository
+type RepositoryOption func(*Repository)
+
+// WithStreamNamer sets the StreamNamer used to find the stream of an Aggregate. By default the id is
+// used as the stream name.
+func WithStreamNamer(namer StreamNamer) RepositoryOption {
+	return func(r *Repository) {
+		r.streamName = namer
+	}
+}
+
+// WithMaxRetries sets how many times Update retries a command after a concurrency conflict
+func WithMaxRetries(maxRetries int) RepositoryOption {
+	return func(r *Repository) {
+		r.maxRetries = maxRetries
+	}
+}
+
+// WithStreams makes a Repository read and write the streams of its Aggregates through streams
+func WithStreams(streams Streams) RepositoryOption {
+	return func(r *Repository) {
+		r.forwardsReader = streams.FowardsReader
+		r.backwardsReader = streams.BackwardsReader
+		r.writer = streams.Writer
+		r.setMetadata = streams.SetStreamMetadata
+		r.readMetadata = nil
+		if reader, ok := streams.(metadataReader); ok {
+			r.readMetadata = reader.StreamMetadata
+		}
+	}
+}
+
+// NewRepository creates a new Repository for the Aggregates created by factory
+func NewRepository(slinger Slinger, factory AggregateFactory, options ...RepositoryOption) *Repository {
+	r := &Repository{
+		factory: factory,
+		streamName: func(id string) string {
+			return id
+		},
+		maxRetries: defaultMaxRetries,
+		forwardsReader: func(stream string) Reader {
+			return NewForwardsReader(slinger, stream)
+		},
+		backwardsReader: func(stream string) Reader {
+			return NewBackwardsReader(slinger, stream)
+		},
+		writer: func(stream string) Writer {
+			return NewWriter(slinger, stream)
+		},
+		setMetadata: func(ctx context.Context, stream string, metadata StreamMetadata) error {
+			return SetStreamMetadata(ctx, slinger, stream, metadata)
+		},
+		readMetadata: func(ctx context.Context, stream string) (StreamMetadata, error) {
+			return ReadStreamMetadata(ctx, slinger, stream)
+		},
+	}
+	for _, opt := range options {
+		opt(r)
+	}
+
+	return r
+}
+
+// Load creates an Aggregate and replays the Events of its stream on it. A stream that does not exist
+// yields a new Aggregate. When snapshots are enabled, the latest snapshot is restored first and only the
+// Events after it are replayed.
+func (r *Repository) Load(ctx context.Context, id string) (Aggregate, error) {
+	aggregate := r.factory(id)
+	stream := r.streamName(id)
+	reader := r.forwardsReader(stream)
+
+	var next int64
+	if snapshotter, ok := aggregate.(SnapshotAggregate); ok && r.snapshotPolicy != nil {
+		var err error
+		next, err = r.loadSnapshot(ctx, stream, snapshotter)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		events, err := reader.Read(ctx, next, loadCount)
+		if errors.Is(err, ErrStreamNotFound) {
+			return aggregate, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, event := range events {
+			aggregate.Apply(event)
+		}
+
+		if len(events) < loadCount {
+			return aggregate, nil
+		}
+		next += int64(len(events))
+	}
+}
+
+// Save writes the changes of an Aggregate to its stream, expecting the stream to be at the version the
+// Aggregate was loaded at. It returns ErrWrongExpectedVersion if the stream was written to in the meantime.
+//
+// When the snapshot policy asks for it, a snapshot is written after the changes. Failing to write the
+// snapshot is not reported, since the changes have been saved and a later snapshot will catch up.
+func (r *Repository) Save(ctx context.Context, id string, aggregate Aggregate) error {
+	changes := aggregate.Changes()
+	if len(changes) == 0 {
+		return nil
+	}
+
+	expectedVersion := aggregate.Version()
+	events := make(Events, len(changes))
+	for i, event := range changes {
+		event.Version = expectedVersion + 1 + int64(i)
+		events[i] = event
+	}
+
+	stream := r.streamName(id)
+	if err := r.writer(stream).Write(ctx, expectedVersion, events...); err != nil {
+		return err
+	}
+
+	version := expectedVersion + int64(len(events))
+	if snapshotter, ok := aggregate.(SnapshotAggregate); ok && r.snapshotPolicy != nil && r.snapshotPolicy(expectedVersion, version) {
+		r.saveSnapshot(ctx, stream, snapshotter, version)
+	}
+
+	return nil
+}
+
+// Update loads an Aggregate, executes a command on it, and saves its changes. When the stream was
+// written to concurrently, the Aggregate is reloaded and the command is executed again, up to the
+// configured number of retries.
+func (r *Repository) Update(ctx context.Context, id string, command func(Aggregate) error) error {
+	var err error
+	for attempt := 0; attempt <= r.maxRetries; attempt++ {
+		var aggregate Aggregate
+		aggregate, err = r.Load(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err = command(aggregate); err != nil {
+			return err
+		}
+
+		err = r.Save(ctx, id, aggregate)
+		if !errors.Is(err, ErrWrongExpectedVersion) {
+			return err
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,153 @@
+package goro_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/dghubble/sling"
+	"github.com/gorilla/pat"
+	"github.com/stretchr/testify/assert"
+	"github.com/vectorhacker/goro"
+)
+
+type account struct {
+	version int64
+	balance int
+	changes goro.Events
+}
+
+func (a *account) Apply(event goro.Event) {
+	deposit := struct {
+		Amount int `json:"amount"`
+	}{}
+	json.Unmarshal(event.Data, &deposit)
+	a.balance += deposit.Amount
+	a.version = event.Version
+}
+
+func (a *account) Changes() goro.Events {
+	return a.changes
+}
+
+func (a *account) Version() int64 {
+	return a.version
+}
+
+func (a *account) deposit(amount int) {
+	a.changes = append(a.changes, goro.CreateEvent(
+		"deposit",
+		[]byte("{\"amount\":"+strconv.Itoa(amount)+"}"),
+		nil,
+		0,
+	))
+}
+
+func TestRepository(t *testing.T) {
+	newAccount := func(id string) goro.Aggregate {
+		return &account{version: goro.ExpectedVersionNone}
+	}
+
+	t.Run("it should load and save an aggregate", func(t *testing.T) {
+		mux := pat.New()
+		mux.Get("/streams/{stream}/{start}/{direction}/{pageSize}", func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "account-1", r.URL.Query().Get(":stream"))
+			assert.Equal(t, "0", r.URL.Query().Get(":start"))
+
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"entries": goro.Events{
+					{ID: goro.NewUUID(), Type: "deposit", Version: 0, Data: []byte("{\"amount\":10}")},
+					{ID: goro.NewUUID(), Type: "deposit", Version: 1, Data: []byte("{\"amount\":5}")},
+				},
+			})
+		})
+		mux.Post("/streams/{stream}", func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "account-1", r.URL.Query().Get(":stream"))
+			assert.Equal(t, "1", r.Header.Get("ES-ExpectedVersion"))
+
+			events := goro.Events{}
+			err := json.NewDecoder(r.Body).Decode(&events)
+			assert.Nil(t, err)
+			assert.Len(t, events, 1)
+
+			w.WriteHeader(http.StatusCreated)
+		})
+		s := httptest.NewServer(mux)
+		defer s.Close()
+
+		repository := goro.NewRepository(goro.SlingerFunc(func() *sling.Sling {
+			return sling.New().Base(s.URL).Client(s.Client()).New()
+		}), newAccount, goro.WithStreamNamer(goro.CategoryStreamNamer("account")))
+
+		ctx := context.Background()
+		aggregate, err := repository.Load(ctx, "1")
+		assert.Nil(t, err)
+		assert.Equal(t, 15, aggregate.(*account).balance)
+		assert.Equal(t, int64(1), aggregate.Version())
+
+		aggregate.(*account).deposit(20)
+		err = repository.Save(ctx, "1", aggregate)
+		assert.Nil(t, err)
+	})
+
+	t.Run("it should retry a command after a concurrency conflict", func(t *testing.T) {
+		writes := 0
+		mux := pat.New()
+		mux.Get("/streams/{stream}/{start}/{direction}/{pageSize}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+		mux.Post("/streams/{stream}", func(w http.ResponseWriter, r *http.Request) {
+			writes++
+			if writes == 1 {
+				w.Header().Set("ES-CurrentVersion", "0")
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			w.WriteHeader(http.StatusCreated)
+		})
+		s := httptest.NewServer(mux)
+		defer s.Close()
+
+		repository := goro.NewRepository(goro.SlingerFunc(func() *sling.Sling {
+			return sling.New().Base(s.URL).Client(s.Client()).New()
+		}), newAccount)
+
+		commands := 0
+		err := repository.Update(context.Background(), "1", func(aggregate goro.Aggregate) error {
+			commands++
+			aggregate.(*account).deposit(10)
+			return nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, commands)
+		assert.Equal(t, 2, writes)
+	})
+
+	t.Run("it should give up after the max retries", func(t *testing.T) {
+		mux := pat.New()
+		mux.Get("/streams/{stream}/{start}/{direction}/{pageSize}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+		mux.Post("/streams/{stream}", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("ES-CurrentVersion", "0")
+			w.WriteHeader(http.StatusBadRequest)
+		})
+		s := httptest.NewServer(mux)
+		defer s.Close()
+
+		repository := goro.NewRepository(goro.SlingerFunc(func() *sling.Sling {
+			return sling.New().Base(s.URL).Client(s.Client()).New()
+		}), newAccount, goro.WithMaxRetries(1))
+
+		err := repository.Update(context.Background(), "1", func(aggregate goro.Aggregate) error {
+			aggregate.(*account).deposit(10)
+			return nil
+		})
+		assert.True(t, errors.Is(err, goro.ErrWrongExpectedVersion), "%v", err)
+	})
+}
@@ -0,0 +1,165 @@
+package goro
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/dghubble/sling"
+)
+
+// RetryPolicy decides which failed requests a Client sends again, and when. Reads are always retryable;
+// writes only when their Events have ids, since Event Store ignores Events it already has, and acks and
+// nacks are retryable too.
+type RetryPolicy struct {
+	// MaxAttempts is the number of times a request is sent, including the first time
+	MaxAttempts int
+	// Backoff returns how long to wait before a retry, from 1 for the first retry
+	Backoff func(retry int) time.Duration
+	// RetryableStatuses are the statuses of the responses that are retried
+	RetryableStatuses []int
+	// RetryableError reports whether a request that failed without a response is retried. By default
+	// every error is, except the cancellation of the request.
+	RetryableError func(err error) bool
+}
+
+// DefaultRetryPolicy sends requests up to 3 times, backing off exponentially from 100ms, and retries
+// connection errors and the statuses Event Store and proxies reply with while a cluster elects a leader
+// or is overloaded
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     ExponentialBackoff(100*time.Millisecond, 2*time.Second),
+		RetryableStatuses: []int{
+			http.StatusRequestTimeout,
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// ExponentialBackoff doubles the wait between retries from initial up to max, with up to 10% of jitter
+func ExponentialBackoff(initial, max time.Duration) func(retry int) time.Duration {
+	return func(retry int) time.Duration {
+		wait := initial
+		for i := 1; i < retry && wait < max; i++ {
+			wait *= 2
+		}
+		if wait > max {
+			wait = max
+		}
+
+		return wait + time.Duration(rand.Int63n(int64(wait)/10+1))
+	}
+}
+
+// WithRetryPolicy retries the failed requests of the Client according to a RetryPolicy
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retryPolicy = &policy
+	}
+}
+
+type idempotentKey struct{}
+
+// idempotent marks the requests made with a context as safe to send more than once
+func idempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+func isIdempotent(req *http.Request) bool {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return true
+	}
+
+	marked, _ := req.Context().Value(idempotentKey{}).(bool)
+	return marked
+}
+
+// retryable reports whether an attempt failed in a way the policy retries
+func (p RetryPolicy) retryable(res *http.Response, err error) bool {
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false
+		}
+		if p.RetryableError != nil {
+			return p.RetryableError(err)
+		}
+		return true
+	}
+
+	for _, status := range p.RetryableStatuses {
+		if res.StatusCode == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// retryDoer is a sling.Doer that sends idempotent requests again when they fail
+type retryDoer struct {
+	doer   sling.Doer
+	policy RetryPolicy
+	logger Logger
+}
+
+// Do implements the sling.Doer interface. It stops retrying when the context of the request is done, or
+// would be before the next retry.
+func (d retryDoer) Do(req *http.Request) (*http.Response, error) {
+	if !isIdempotent(req) || (req.Body != nil && req.GetBody == nil) {
+		return d.doer.Do(req)
+	}
+
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		r := req
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r = req.Clone(ctx)
+			r.Body = body
+		}
+
+		res, err := d.doer.Do(r)
+		if attempt >= d.policy.MaxAttempts || !d.policy.retryable(res, err) {
+			return res, err
+		}
+
+		wait := time.Duration(0)
+		if d.policy.Backoff != nil {
+			wait = d.policy.Backoff(attempt)
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return res, err
+		}
+
+		args := []interface{}{"method", req.Method, "url", redact(req.URL), "attempt", attempt, "wait", wait}
+		if err != nil {
+			args = append(args, "error", err)
+		} else {
+			args = append(args, "status", res.StatusCode)
+		}
+		d.logger.WarnContext(ctx, "retrying request", args...)
+
+		if res != nil {
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package goro_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vectorhacker/goro"
+)
+
+// flakyServer fails the first failures requests with a status, or by closing the connection when the
+// status is 0, and records the bodies of every request
+type flakyServer struct {
+	*httptest.Server
+	mu     sync.Mutex
+	bodies []string
+}
+
+func newFlakyServer(t *testing.T, failures, status int) *flakyServer {
+	s := &flakyServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.bodies = append(s.bodies, string(body))
+		attempt := len(s.bodies)
+		s.mu.Unlock()
+
+		switch {
+		case attempt > failures:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte("{\"entries\":[]}"))
+		case status == 0:
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+		default:
+			w.WriteHeader(status)
+		}
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *flakyServer) attempts() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.bodies...)
+}
+
+func TestRetryPolicy(t *testing.T) {
+	ctx := context.Background()
+	policy := goro.DefaultRetryPolicy()
+	policy.Backoff = func(int) time.Duration {
+		return time.Millisecond
+	}
+	read := func(client *goro.Client) error {
+		_, err := client.FowardsReader("tests").Read(ctx, 0, 1)
+		return err
+	}
+
+	t.Run("it should retry reads", func(t *testing.T) {
+		s := newFlakyServer(t, 2, http.StatusServiceUnavailable)
+		client := goro.Connect(s.URL, goro.WithRetryPolicy(policy))
+
+		assert.Nil(t, read(client))
+		assert.Len(t, s.attempts(), 3)
+	})
+
+	t.Run("it should retry connection errors", func(t *testing.T) {
+		s := newFlakyServer(t, 1, 0)
+		client := goro.Connect(s.URL, goro.WithRetryPolicy(policy))
+
+		assert.Nil(t, read(client))
+		assert.Len(t, s.attempts(), 2)
+	})
+
+	t.Run("it should give up after the max attempts", func(t *testing.T) {
+		s := newFlakyServer(t, 5, http.StatusServiceUnavailable)
+		client := goro.Connect(s.URL, goro.WithRetryPolicy(policy))
+
+		assert.True(t, errors.Is(read(client), goro.ErrUnavailable))
+		assert.Len(t, s.attempts(), 3)
+	})
+
+	t.Run("it should not retry other statuses", func(t *testing.T) {
+		s := newFlakyServer(t, 1, http.StatusNotFound)
+		client := goro.Connect(s.URL, goro.WithRetryPolicy(policy))
+
+		assert.True(t, errors.Is(read(client), goro.ErrStreamNotFound))
+		assert.Len(t, s.attempts(), 1)
+	})
+
+	t.Run("it should not retry without a policy", func(t *testing.T) {
+		s := newFlakyServer(t, 1, http.StatusServiceUnavailable)
+		client := goro.Connect(s.URL)
+
+		assert.True(t, errors.Is(read(client), goro.ErrUnavailable))
+		assert.Len(t, s.attempts(), 1)
+	})
+
+	t.Run("it should retry writes with the same events", func(t *testing.T) {
+		s := newFlakyServer(t, 1, http.StatusServiceUnavailable)
+		client := goro.Connect(s.URL, goro.WithRetryPolicy(policy))
+
+		err := client.Writer("tests").Write(ctx, goro.ExpectedVersionAny, goro.CreateEvent("test", []byte("{}"), nil, 0))
+		assert.Nil(t, err)
+		attempts := s.attempts()
+		assert.Len(t, attempts, 2)
+		assert.Equal(t, attempts[0], attempts[1])
+	})
+
+	t.Run("it should not retry writes of events without ids", func(t *testing.T) {
+		s := newFlakyServer(t, 1, http.StatusServiceUnavailable)
+		client := goro.Connect(s.URL, goro.WithRetryPolicy(policy))
+
+		err := client.Writer("tests").Write(ctx, goro.ExpectedVersionAny, goro.Event{Type: "test", Data: []byte("{}")})
+		assert.True(t, errors.Is(err, goro.ErrUnavailable))
+		assert.Len(t, s.attempts(), 1)
+	})
+
+	t.Run("it should stop retrying at the deadline of the context", func(t *testing.T) {
+		s := newFlakyServer(t, 5, http.StatusServiceUnavailable)
+		slow := goro.DefaultRetryPolicy()
+		slow.Backoff = func(int) time.Duration {
+			return time.Minute
+		}
+		client := goro.Connect(s.URL, goro.WithRetryPolicy(slow))
+
+		ctx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		start := time.Now()
+		_, err := client.FowardsReader("tests").Read(ctx, 0, 1)
+		assert.True(t, errors.Is(err, goro.ErrUnavailable))
+		assert.Len(t, s.attempts(), 1)
+		assert.True(t, time.Since(start) < time.Second)
+	})
+
+	t.Run("it should back off exponentially", func(t *testing.T) {
+		backoff := goro.ExponentialBackoff(100*time.Millisecond, time.Second)
+		assert.InDelta(t, 100*time.Millisecond, backoff(1), float64(10*time.Millisecond))
+		assert.InDelta(t, 400*time.Millisecond, backoff(3), float64(40*time.Millisecond))
+		assert.InDelta(t, time.Second, backoff(10), float64(100*time.Millisecond))
+	})
+}
@@ -0,0 +1,189 @@
+package goro
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+const (
+	pingPath  = "/ping"
+	infoPath  = "/info"
+	statsPath = "/stats"
+)
+
+// Info describes an Event Store node
+type Info struct {
+	// Version is the version of Event Store, like 20.10.0.0
+	Version string `json:"esVersion"`
+	// State is the state of the node in its cluster, like leader or follower, master or slave before
+	// Event Store 20
+	State string `json:"state"`
+	// ProjectionsMode is None, System or All
+	ProjectionsMode string `json:"projectionsMode"`
+	// Features tells which features are enabled, like projections and userManagement. Event Store 20
+	// and later report them.
+	Features map[string]bool `json:"features,omitempty"`
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. Event Store 20 and later nest the projections
+// mode in a projections object.
+func (i *Info) UnmarshalJSON(data []byte) error {
+	type info Info
+	v := struct {
+		*info
+		Projections *struct {
+			Mode string `json:"mode"`
+		} `json:"projections"`
+	}{
+		info: (*info)(i),
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	if v.Projections != nil && i.ProjectionsMode == "" {
+		i.ProjectionsMode = v.Projections.Mode
+	}
+
+	return nil
+}
+
+// AtLeast reports whether the version of Event Store is major.minor or later, to enable behavior that
+// depends on it
+func (i Info) AtLeast(major, minor int) bool {
+	parts := strings.SplitN(i.Version, ".", 3)
+	if len(parts) < 2 {
+		return false
+	}
+
+	m, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return false
+	}
+	n, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return false
+	}
+
+	return m > major || (m == major && n >= minor)
+}
+
+// Stats are the statistics of an Event Store node, from its process, its system and Event Store itself
+type Stats struct {
+	Process    ProcessStats `json:"proc"`
+	System     SystemStats  `json:"sys"`
+	EventStore ServerStats  `json:"es"`
+}
+
+// ProcessStats are the statistics of the Event Store process
+type ProcessStats struct {
+	ID           int64       `json:"id"`
+	StartTime    string      `json:"startTime"`
+	Memory       int64       `json:"mem"`
+	CPU          float64     `json:"cpu"`
+	ThreadsCount int64       `json:"threadsCount"`
+	DiskIO       DiskIOStats `json:"diskIo"`
+}
+
+// DiskIOStats are the reads and writes of the Event Store process
+type DiskIOStats struct {
+	ReadBytes    int64 `json:"readBytes"`
+	WrittenBytes int64 `json:"writtenBytes"`
+	ReadOps      int64 `json:"readOps"`
+	WriteOps     int64 `json:"writeOps"`
+}
+
+// SystemStats are the statistics of the machine Event Store runs on
+type SystemStats struct {
+	CPU        float64 `json:"cpu"`
+	FreeMemory int64   `json:"freeMem"`
+	// Drives are the drives of the database, by path
+	Drives map[string]DriveStats `json:"drive"`
+}
+
+// DriveStats describe the usage of a drive
+type DriveStats struct {
+	AvailableBytes int64 `json:"availableBytes"`
+	TotalBytes     int64 `json:"totalBytes"`
+	UsedBytes      int64 `json:"usedBytes"`
+	// Usage is the used percentage, like 27%
+	Usage string `json:"usage"`
+}
+
+// ServerStats are the statistics of Event Store itself
+type ServerStats struct {
+	// Queues are the internal queues of Event Store, by name
+	Queues    map[string]QueueStats `json:"queue"`
+	ReadIndex CacheStats            `json:"readIndex"`
+}
+
+// QueueStats describe an internal queue of Event Store. A queue that keeps growing means the node can't
+// keep up with its load.
+type QueueStats struct {
+	Name                string  `json:"queueName"`
+	GroupName           string  `json:"groupName"`
+	Length              int64   `json:"length"`
+	LengthLifetimePeak  int64   `json:"lengthLifetimePeak"`
+	AvgItemsPerSecond   float64 `json:"avgItemsPerSecond"`
+	AvgProcessingTime   float64 `json:"avgProcessingTime"`
+	IdleTimePercent     float64 `json:"idleTimePercent"`
+	TotalItemsProcessed int64   `json:"totalItemsProcessed"`
+}
+
+// CacheStats are the hits and misses of the caches of the read index
+type CacheStats struct {
+	CachedRecord        int64 `json:"cachedRecord"`
+	NotCachedRecord     int64 `json:"notCachedRecord"`
+	CachedStreamInfo    int64 `json:"cachedStreamInfo"`
+	NotCachedStreamInfo int64 `json:"notCachedStreamInfo"`
+	CachedTransInfo     int64 `json:"cachedTransInfo"`
+	NotCachedTransInfo  int64 `json:"notCachedTransInfo"`
+}
+
+// Ping checks that Event Store is up and handling requests
+func Ping(ctx context.Context, slinger Slinger) error {
+	return getJSON(ctx, slinger, pingPath, nil)
+}
+
+// ReadInfo reads the version, state and features of Event Store
+func ReadInfo(ctx context.Context, slinger Slinger) (Info, error) {
+	info := Info{}
+	if err := getJSON(ctx, slinger, infoPath, &info); err != nil {
+		return Info{}, err
+	}
+
+	return info, nil
+}
+
+// ReadStats reads the statistics of Event Store
+func ReadStats(ctx context.Context, slinger Slinger) (Stats, error) {
+	stats := Stats{}
+	if err := getJSON(ctx, slinger, statsPath, &stats); err != nil {
+		return Stats{}, err
+	}
+
+	return stats, nil
+}
+
+// getJSON reads a json resource of Event Store into v
+func getJSON(ctx context.Context, slinger Slinger, path string, v interface{}) error {
+	req, err := slinger.
+		Sling().
+		Get(path).
+		Set("Accept", jsonContentType).
+		Request()
+	if err != nil {
+		return err
+	}
+
+	req = req.WithContext(ctx)
+
+	res, err := slinger.Sling().Do(req, v, nil)
+	if err != nil {
+		return err
+	}
+
+	return relevantError(res)
+}
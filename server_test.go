@@ -0,0 +1,165 @@
+package goro_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vectorhacker/goro"
+)
+
+// node serves the same json body for every request, with a status
+func node(t *testing.T, path string, status int, body string) *goro.Client {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, path, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(s.Close)
+
+	return goro.Connect(s.URL, goro.WithHTTPClient(s.Client()))
+}
+
+func TestPing(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("it should ping a node", func(t *testing.T) {
+		client := node(t, "/ping", http.StatusOK, "{\"text\":\"Ping request successfully handled\"}")
+		assert.Nil(t, client.Ping(ctx))
+	})
+
+	t.Run("it should fail when the node is unavailable", func(t *testing.T) {
+		client := node(t, "/ping", http.StatusServiceUnavailable, "")
+		err := client.Ping(ctx)
+		assert.True(t, errors.Is(err, goro.ErrUnavailable), "%v", err)
+	})
+}
+
+func TestInfo(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("it should read the info of Event Store 20", func(t *testing.T) {
+		client := node(t, "/info", http.StatusOK, `{
+			"esVersion": "20.10.2.0",
+			"state": "leader",
+			"projections": {"mode": "System"},
+			"features": {"projections": true, "userManagement": true, "atomPub": true}
+		}`)
+
+		info, err := client.Info(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, goro.Info{
+			Version:         "20.10.2.0",
+			State:           "leader",
+			ProjectionsMode: "System",
+			Features: map[string]bool{
+				"projections":    true,
+				"userManagement": true,
+				"atomPub":        true,
+			},
+		}, info)
+	})
+
+	t.Run("it should read the info of Event Store 5", func(t *testing.T) {
+		client := node(t, "/info", http.StatusOK, "{\"esVersion\":\"5.0.8.0\",\"state\":\"master\",\"projectionsMode\":\"None\"}")
+
+		info, err := client.Info(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, goro.Info{Version: "5.0.8.0", State: "master", ProjectionsMode: "None"}, info)
+	})
+
+	t.Run("it should compare versions", func(t *testing.T) {
+		info := goro.Info{Version: "20.10.2.0"}
+		assert.True(t, info.AtLeast(5, 0))
+		assert.True(t, info.AtLeast(20, 10))
+		assert.False(t, info.AtLeast(20, 11))
+		assert.False(t, info.AtLeast(21, 0))
+		assert.False(t, goro.Info{Version: "unknown"}.AtLeast(0, 0))
+	})
+}
+
+func TestStats(t *testing.T) {
+	client := node(t, "/stats", http.StatusOK, `{
+		"proc": {
+			"startTime": "2020-11-12T10:20:30Z",
+			"id": 1,
+			"mem": 104857600,
+			"cpu": 2.5,
+			"threadsCount": 42,
+			"diskIo": {"readBytes": 1024, "writtenBytes": 2048, "readOps": 10, "writeOps": 20}
+		},
+		"sys": {
+			"cpu": 12.5,
+			"freeMem": 536870912,
+			"drive": {
+				"/var/lib/eventstore": {"availableBytes": 750, "totalBytes": 1000, "usage": "25%", "usedBytes": 250}
+			}
+		},
+		"es": {
+			"queue": {
+				"MainQueue": {
+					"queueName": "MainQueue",
+					"groupName": "",
+					"avgItemsPerSecond": 120,
+					"avgProcessingTime": 0.5,
+					"idleTimePercent": 99.5,
+					"length": 3,
+					"lengthLifetimePeak": 250,
+					"totalItemsProcessed": 1000000
+				}
+			},
+			"readIndex": {
+				"cachedRecord": 10,
+				"notCachedRecord": 2,
+				"cachedStreamInfo": 30,
+				"notCachedStreamInfo": 4,
+				"cachedTransInfo": 0,
+				"notCachedTransInfo": 0
+			}
+		}
+	}`)
+
+	stats, err := client.Stats(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, goro.Stats{
+		Process: goro.ProcessStats{
+			ID:           1,
+			StartTime:    "2020-11-12T10:20:30Z",
+			Memory:       104857600,
+			CPU:          2.5,
+			ThreadsCount: 42,
+			DiskIO:       goro.DiskIOStats{ReadBytes: 1024, WrittenBytes: 2048, ReadOps: 10, WriteOps: 20},
+		},
+		System: goro.SystemStats{
+			CPU:        12.5,
+			FreeMemory: 536870912,
+			Drives: map[string]goro.DriveStats{
+				"/var/lib/eventstore": {AvailableBytes: 750, TotalBytes: 1000, UsedBytes: 250, Usage: "25%"},
+			},
+		},
+		EventStore: goro.ServerStats{
+			Queues: map[string]goro.QueueStats{
+				"MainQueue": {
+					Name:                "MainQueue",
+					Length:              3,
+					LengthLifetimePeak:  250,
+					AvgItemsPerSecond:   120,
+					AvgProcessingTime:   0.5,
+					IdleTimePercent:     99.5,
+					TotalItemsProcessed: 1000000,
+				},
+			},
+			ReadIndex: goro.CacheStats{
+				CachedRecord:        10,
+				NotCachedRecord:     2,
+				CachedStreamInfo:    30,
+				NotCachedStreamInfo: 4,
+			},
+		},
+	}, stats)
+}
@@ -0,0 +1,131 @@
+package goro
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+const (
+	snapshotEventType    = "snapshot"
+	snapshotStreamSuffix = "-snapshot"
+)
+
+// SnapshotAggregate is an Aggregate whose state can be saved to, and restored from, a snapshot so that
+// loading it does not require replaying its whole stream.
+type SnapshotAggregate interface {
+	Aggregate
+	// Snapshot returns the json encoded state of the Aggregate, including its Changes
+	Snapshot() (json.RawMessage, error)
+	// RestoreSnapshot replaces the state of the Aggregate with a snapshot taken at version
+	RestoreSnapshot(data json.RawMessage, version int64) error
+}
+
+// SnapshotPolicy decides whether to snapshot an Aggregate after its stream moved from one version to another
+type SnapshotPolicy func(from, to int64) bool
+
+// EveryNEvents is a SnapshotPolicy that snapshots an Aggregate each time its stream grows past a multiple
+// of n Events
+func EveryNEvents(n int64) SnapshotPolicy {
+	return func(from, to int64) bool {
+		if n <= 0 {
+			return false
+		}
+
+		return (from+1)/n != (to+1)/n
+	}
+}
+
+// SnapshotStream returns the name of the companion stream snapshots of a stream are stored in
+func SnapshotStream(stream string) string {
+	return stream + snapshotStreamSuffix
+}
+
+type snapshotMetadata struct {
+	Version int64 `json:"aggregateVersion"`
+}
+
+// WithSnapshots enables snapshots for the SnapshotAggregates of a Repository. Snapshots are written to a
+// companion stream that keeps only the latest one, whenever the policy asks for it.
+func WithSnapshots(policy SnapshotPolicy) RepositoryOption {
+	return func(r *Repository) {
+		r.snapshotPolicy = policy
+	}
+}
+
+// loadSnapshot restores the latest snapshot of an Aggregate, if there is one, and returns the version to
+// continue reading its stream from.
+func (r *Repository) loadSnapshot(ctx context.Context, stream string, aggregate SnapshotAggregate) (int64, error) {
+	events, err := r.backwardsReader(SnapshotStream(stream)).Read(ctx, StreamHead, 1)
+	if errors.Is(err, ErrStreamNotFound) || (err == nil && len(events) == 0) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	metadata := snapshotMetadata{}
+	if err := json.Unmarshal(events[0].Metadata, &metadata); err != nil {
+		return 0, err
+	}
+
+	if err := aggregate.RestoreSnapshot(events[0].Data, metadata.Version); err != nil {
+		return 0, err
+	}
+
+	return metadata.Version + 1, nil
+}
+
+// saveSnapshot writes a snapshot of an Aggregate taken at version to the companion stream. The stream is
+// limited to its latest snapshot when the first one is written, or by a later one if that failed.
+func (r *Repository) saveSnapshot(ctx context.Context, stream string, aggregate SnapshotAggregate, version int64) error {
+	data, err := aggregate.Snapshot()
+	if err != nil {
+		return err
+	}
+
+	metadata, err := json.Marshal(snapshotMetadata{
+		Version: version,
+	})
+	if err != nil {
+		return err
+	}
+
+	snapshotStream := SnapshotStream(stream)
+	event := CreateEvent(snapshotEventType, data, metadata, 0)
+	err = r.writer(snapshotStream).Write(ctx, ExpectedVersionNone, event)
+	if errors.Is(err, ErrWrongExpectedVersion) {
+		// the stream already has snapshots, and was limited with the first one unless that failed
+		if err := r.writer(snapshotStream).Write(ctx, ExpectedVersionAny, event); err != nil {
+			return err
+		}
+
+		return r.limitSnapshots(ctx, snapshotStream)
+	}
+	if err != nil {
+		return err
+	}
+
+	return r.setMetadata(ctx, snapshotStream, StreamMetadata{
+		MaxCount: 1,
+	})
+}
+
+// limitSnapshots limits a snapshot stream to its latest snapshot, unless its metadata shows it is already.
+// Without a way to read the metadata, the limit is set again.
+func (r *Repository) limitSnapshots(ctx context.Context, snapshotStream string) error {
+	metadata := StreamMetadata{}
+	if r.readMetadata != nil {
+		var err error
+		metadata, err = r.readMetadata(ctx, snapshotStream)
+		if err != nil {
+			return err
+		}
+		if metadata.MaxCount == 1 {
+			return nil
+		}
+	}
+
+	metadata.MaxCount = 1
+	return r.setMetadata(ctx, snapshotStream, metadata)
+}
@@ -0,0 +1,204 @@
+package goro_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dghubble/sling"
+	"github.com/gorilla/pat"
+	"github.com/stretchr/testify/assert"
+	"github.com/vectorhacker/goro"
+	"github.com/vectorhacker/goro/memory"
+)
+
+type snapshotAccount struct {
+	account
+}
+
+func (a *snapshotAccount) Snapshot() (json.RawMessage, error) {
+	balance := a.balance
+	for _, event := range a.changes {
+		deposit := struct {
+			Amount int `json:"amount"`
+		}{}
+		json.Unmarshal(event.Data, &deposit)
+		balance += deposit.Amount
+	}
+
+	return json.Marshal(map[string]int{"balance": balance})
+}
+
+func (a *snapshotAccount) RestoreSnapshot(data json.RawMessage, version int64) error {
+	state := struct {
+		Balance int `json:"balance"`
+	}{}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+
+	a.balance = state.Balance
+	a.version = version
+	return nil
+}
+
+func TestEveryNEvents(t *testing.T) {
+	policy := goro.EveryNEvents(5)
+
+	assert.False(t, policy(goro.ExpectedVersionNone, 3))
+	assert.True(t, policy(goro.ExpectedVersionNone, 4))
+	assert.True(t, policy(3, 5))
+	assert.False(t, policy(4, 8))
+	assert.False(t, goro.EveryNEvents(0)(0, 100))
+}
+
+func TestRepositorySnapshots(t *testing.T) {
+	newAccount := func(id string) goro.Aggregate {
+		return &snapshotAccount{account{version: goro.ExpectedVersionNone}}
+	}
+
+	var calledMetadata, calledSnapshot bool
+
+	mux := pat.New()
+	mux.Get("/streams/{stream}/{start}/{direction}/{pageSize}", func(w http.ResponseWriter, r *http.Request) {
+		stream := r.URL.Query().Get(":stream")
+		start := r.URL.Query().Get(":start")
+		direction := r.URL.Query().Get(":direction")
+
+		if stream == "account-1-snapshot" {
+			assert.Equal(t, "head", start)
+			assert.Equal(t, "backward", direction)
+			assert.Equal(t, "1", r.URL.Query().Get(":pageSize"))
+
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"entries": goro.Events{
+					{ID: goro.NewUUID(), Type: "snapshot", Data: []byte("{\"balance\":100}"), Metadata: []byte("{\"aggregateVersion\":7}")},
+				},
+			})
+			return
+		}
+
+		assert.Equal(t, "account-1", stream)
+		assert.Equal(t, "8", start)
+		assert.Equal(t, "forward", direction)
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"entries": goro.Events{
+				{ID: goro.NewUUID(), Type: "deposit", Version: 8, Data: []byte("{\"amount\":5}")},
+			},
+		})
+	})
+	mux.Post("/streams/{stream}/metadata", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "account-1-snapshot", r.URL.Query().Get(":stream"))
+
+		events := goro.Events{}
+		err := json.NewDecoder(r.Body).Decode(&events)
+		assert.Nil(t, err)
+		assert.Len(t, events, 1)
+		assert.JSONEq(t, "{\"$maxCount\":1}", string(events[0].Data))
+
+		calledMetadata = true
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.Post("/streams/{stream}", func(w http.ResponseWriter, r *http.Request) {
+		events := goro.Events{}
+		err := json.NewDecoder(r.Body).Decode(&events)
+		assert.Nil(t, err)
+
+		if r.URL.Query().Get(":stream") == "account-1-snapshot" {
+			if r.Header.Get("ES-ExpectedVersion") == "-1" {
+				// the stream already has a snapshot
+				w.Header().Set("ES-CurrentVersion", "0")
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			assert.Equal(t, "-2", r.Header.Get("ES-ExpectedVersion"))
+			assert.Len(t, events, 1)
+			assert.JSONEq(t, "{\"balance\":125}", string(events[0].Data))
+			assert.JSONEq(t, "{\"aggregateVersion\":10}", string(events[0].Metadata))
+			calledSnapshot = true
+		} else {
+			assert.Equal(t, "8", r.Header.Get("ES-ExpectedVersion"))
+		}
+
+		w.WriteHeader(http.StatusCreated)
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	repository := goro.NewRepository(goro.SlingerFunc(func() *sling.Sling {
+		return sling.New().Base(s.URL).Client(s.Client()).New()
+	}), newAccount,
+		goro.WithStreamNamer(goro.CategoryStreamNamer("account")),
+		goro.WithSnapshots(goro.EveryNEvents(10)),
+	)
+
+	ctx := context.Background()
+	aggregate, err := repository.Load(ctx, "1")
+	assert.Nil(t, err)
+	assert.Equal(t, 105, aggregate.(*snapshotAccount).balance)
+	assert.Equal(t, int64(8), aggregate.Version())
+
+	aggregate.(*snapshotAccount).deposit(10)
+	aggregate.(*snapshotAccount).deposit(10)
+	err = repository.Save(ctx, "1", aggregate)
+	assert.Nil(t, err)
+
+	assert.False(t, calledMetadata)
+	assert.True(t, calledSnapshot)
+}
+
+// metadataCounter counts the times the metadata of streams is set, and fails the first ones
+type metadataCounter struct {
+	*memory.Store
+	calls    int
+	failures int
+}
+
+func (c *metadataCounter) SetStreamMetadata(ctx context.Context, stream string, metadata goro.StreamMetadata) error {
+	c.calls++
+	if c.calls <= c.failures {
+		return errors.New("unavailable")
+	}
+	return c.Store.SetStreamMetadata(ctx, stream, metadata)
+}
+
+func TestSnapshotStreamLimit(t *testing.T) {
+	newAccount := func(id string) goro.Aggregate {
+		return &snapshotAccount{account{version: goro.ExpectedVersionNone}}
+	}
+	ctx := context.Background()
+	save := func(streams *metadataCounter) {
+		repository := goro.NewRepository(nil, newAccount, goro.WithStreams(streams), goro.WithSnapshots(goro.EveryNEvents(1)))
+		for i := 0; i < 3; i++ {
+			assert.Nil(t, repository.Update(ctx, "account-1", func(aggregate goro.Aggregate) error {
+				aggregate.(*snapshotAccount).deposit(10)
+				return nil
+			}))
+		}
+	}
+
+	t.Run("it should limit the snapshot stream once", func(t *testing.T) {
+		streams := &metadataCounter{Store: memory.NewStore()}
+		save(streams)
+
+		assert.Equal(t, 1, streams.calls)
+		metadata, err := streams.StreamMetadata(ctx, "account-1-snapshot")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), metadata.MaxCount)
+	})
+
+	t.Run("it should limit the snapshot stream when limiting it with the first snapshot failed", func(t *testing.T) {
+		streams := &metadataCounter{Store: memory.NewStore(), failures: 1}
+		save(streams)
+
+		assert.Equal(t, 2, streams.calls)
+		metadata, err := streams.StreamMetadata(ctx, "account-1-snapshot")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), metadata.MaxCount)
+	})
+}
@@ -4,8 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"sort"
+	"net/http"
 	"strings"
+	"time"
 
 	"github.com/dghubble/sling"
 	"github.com/satori/go.uuid"
@@ -24,6 +25,9 @@ type catchupSubscription struct {
 	stream  string
 	start   int64
 	slinger Slinger
+	tracer  Tracer
+	metrics MetricsCollector
+	logger  Logger
 }
 
 // NewCatchupSubscription creates a Subscriber that starts reading a stream from a specific event and then
@@ -33,48 +37,39 @@ func NewCatchupSubscription(slinger Slinger, stream string, startFrom int64) Sub
 		stream:  stream,
 		start:   startFrom,
 		slinger: slinger,
+		tracer:  tracerOf(slinger),
+		metrics: metricsOf(slinger),
+		logger:  loggerOf(slinger),
 	}
 }
 
 // Subscribe implements the Subscriber interface
 func (s *catchupSubscription) Subscribe(ctx context.Context) <-chan StreamMessage {
 	stream := make(chan StreamMessage)
-	response := struct {
-		Events Events `json:"entries"`
-	}{}
 
 	go func() {
 		defer close(stream)
 		next := s.start
 
 		for {
-			path := fmt.Sprintf("/streams/%s/%d/forward/%d", s.stream, next, readCount)
-			res, err := s.slinger.
-				Sling().
-				Get(path).
-				Add("Accept", "application/vnd.eventstore.atom+json").
-				Set("ES-LongPoll", longPollTimeout).
-				QueryStruct(&embedParams{
-					Embed: "body",
-				}).
-				ReceiveSuccess(&response)
-			if err != nil {
-				stream <- StreamMessage{
-					Error: err,
-				}
+			events, head, err := s.poll(ctx, next)
+			if ctx.Err() != nil {
+				// the long poll was cancelled
 				return
 			}
-
-			err = relevantError(res.StatusCode)
 			if err != nil {
+				s.logger.ErrorContext(ctx, "catchup subscription failed", "stream", s.stream, "error", err)
 				stream <- StreamMessage{
 					Error: err,
 				}
 				return
 			}
 
-			sort.Sort(response.Events)
-			for _, event := range response.Events {
+			if len(events) == 0 && head >= 0 {
+				s.lag(head, next-1)
+			}
+
+			for _, event := range events {
 				select {
 				case <-ctx.Done():
 					return
@@ -82,13 +77,17 @@ func (s *catchupSubscription) Subscribe(ctx context.Context) <-chan StreamMessag
 					Event: event,
 				}:
 				}
+
+				if head >= 0 {
+					s.lag(head, position(event))
+				}
 			}
 
 			select {
 			case <-ctx.Done():
 				return
 			default:
-				next += int64(len(response.Events))
+				next += int64(len(events))
 			}
 		}
 	}()
@@ -96,10 +95,96 @@ func (s *catchupSubscription) Subscribe(ctx context.Context) <-chan StreamMessag
 	return stream
 }
 
+// lag records how far the last delivered Event is behind the head of the stream
+func (s *catchupSubscription) lag(head, delivered int64) {
+	lag := head - delivered
+	if lag < 0 {
+		lag = 0
+	}
+
+	s.metrics.SubscriptionLag(s.stream, lag)
+}
+
+// poll long polls the next page of the stream. It returns the version of the last Event of the stream
+// too, or -1 if Event Store didn't tell.
+func (s *catchupSubscription) poll(ctx context.Context, next int64) (events Events, head int64, err error) {
+	ctx, span := s.tracer.Start(ctx, SpanSubscriptionPoll)
+	span.SetAttribute(AttributeStream, s.stream)
+	start := time.Now()
+	var res *http.Response
+	defer func() {
+		span.SetAttribute(AttributeEventCount, len(events))
+		endSpan(span, res, err)
+		s.metrics.ObserveRequest(OperationSubscriptionPoll, time.Since(start), err)
+		if err == nil {
+			s.metrics.EventsRead(s.stream, len(events))
+			s.logger.DebugContext(ctx, "fetched page", "stream", s.stream, "from", next, "events", len(events))
+		}
+	}()
+
+	path := fmt.Sprintf("/streams/%s/%d/forward/%d", s.stream, next, readCount)
+	req, err := s.slinger.
+		Sling().
+		Get(path).
+		Add("Accept", "application/vnd.eventstore.atom+json").
+		Set("ES-LongPoll", longPollTimeout).
+		QueryStruct(&embedParams{
+			Embed: "body",
+		}).
+		Request()
+	if err != nil {
+		return nil, -1, err
+	}
+
+	response := feed{}
+	res, err = s.slinger.Sling().Do(req.WithContext(ctx), &response, nil)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	err = relevantError(res)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	events, err = response.read(ctx, s.slinger)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	head, ok := response.head()
+	if !ok {
+		head = -1
+	}
+
+	return events, head, nil
+}
+
 type persistentSubscription struct {
 	stream           string
 	subscriptionName string
 	slinger          Slinger
+	tracer           Tracer
+	metrics          MetricsCollector
+	logger           Logger
+}
+
+// PersistentSubscriptionOptions are the options of creating a persistent subscription
+type PersistentSubscriptionOptions struct {
+	// FailIfExists fails with ErrConflict when the subscription already exists, instead of joining it
+	FailIfExists bool
+}
+
+// PersistentSubscriptionOption applies options to the creation of a persistent subscription
+type PersistentSubscriptionOption func(*PersistentSubscriptionOptions)
+
+// FailIfExists fails with ErrConflict when the subscription already exists. By default an existing
+// subscription is joined, so that several consumers, or a consumer that restarts, share it, and its
+// settings are left as they are.
+func FailIfExists() PersistentSubscriptionOption {
+	return func(o *PersistentSubscriptionOptions) {
+		o.FailIfExists = true
+	}
 }
 
 // PersistentSubscriptionSettings represents the settings for creating and updating a Persistent subscription.
@@ -120,96 +205,137 @@ type PersistentSubscriptionSettings struct {
 	NamedConsumerStrategy       string `json:"namedConsumerStrategy,omitempty"`
 }
 
-// NewPersistentSubscription creates a new subscription that implements the competing consumers pattern
+// NewPersistentSubscription creates a new subscription that implements the competing consumers pattern.
+// It joins the subscription when it already exists.
 func NewPersistentSubscription(slinger Slinger, stream, subscriptionName string, settings PersistentSubscriptionSettings) (Subscriber, error) {
+	return NewPersistentSubscriptionContext(context.Background(), slinger, stream, subscriptionName, settings)
+}
+
+// NewPersistentSubscriptionContext is NewPersistentSubscription with options, which creates the
+// subscription with ctx. Pass FailIfExists to fail with ErrConflict when the subscription already exists.
+func NewPersistentSubscriptionContext(ctx context.Context, slinger Slinger, stream, subscriptionName string, settings PersistentSubscriptionSettings, options ...PersistentSubscriptionOption) (Subscriber, error) {
 	s := &persistentSubscription{
 		slinger:          slinger,
 		subscriptionName: subscriptionName,
 		stream:           stream,
+		tracer:           tracerOf(slinger),
+		metrics:          metricsOf(slinger),
+		logger:           loggerOf(slinger),
+	}
+	opts := PersistentSubscriptionOptions{}
+	for _, opt := range options {
+		opt(&opts)
 	}
 
-	res, err := s.slinger.
+	req, err := s.slinger.
 		Sling().
 		Put(fmt.Sprintf("/subscriptions/%s/%s", stream, subscriptionName)).
 		BodyJSON(settings).
-		ReceiveSuccess(nil)
+		Request()
 	if err != nil {
 		return nil, err
 	}
 
-	return s, relevantError(res.StatusCode)
+	if !opts.FailIfExists {
+		// creating the subscription again joins it, so it can be retried
+		ctx = idempotent(ctx)
+	}
+
+	res, err := s.slinger.Sling().Do(req.WithContext(ctx), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = relevantError(res)
+	if err != nil && !(!opts.FailIfExists && errors.Is(err, ErrConflict)) {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// wrappingSubscriber is a Subscriber that wraps another one, like the mapping and decoding Subscribers
+type wrappingSubscriber interface {
+	unwrap() Subscriber
 }
 
-// UpdatePersistentSubscription updates an existing subscription if it's Persistent
+// UpdatePersistentSubscription updates an existing subscription if it's Persistent, or wraps one, like the
+// decompressing subscriptions of a Client
 func UpdatePersistentSubscription(subscription Subscriber, newSettings PersistentSubscriptionSettings) (Subscriber, error) {
+	return UpdatePersistentSubscriptionContext(context.Background(), subscription, newSettings)
+}
+
+// UpdatePersistentSubscriptionContext is UpdatePersistentSubscription, which updates the subscription
+// with ctx
+func UpdatePersistentSubscriptionContext(ctx context.Context, subscription Subscriber, newSettings PersistentSubscriptionSettings) (Subscriber, error) {
+	if w, ok := subscription.(wrappingSubscriber); ok {
+		if _, err := UpdatePersistentSubscriptionContext(ctx, w.unwrap(), newSettings); err != nil {
+			return nil, err
+		}
+
+		return subscription, nil
+	}
+
 	s, ok := subscription.(*persistentSubscription)
 	if !ok {
 		return nil, errors.New("not a Persistent Subscription")
 	}
 
-	res, err := s.slinger.
+	req, err := s.slinger.
 		Sling().
 		Post(fmt.Sprintf("/subscriptions/%s/%s", s.stream, s.subscriptionName)).
 		BodyJSON(newSettings).
-		ReceiveSuccess(nil)
+		Request()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.slinger.Sling().Do(req.WithContext(ctx), nil, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return subscription, relevantError(res.StatusCode)
+	return subscription, relevantError(res)
 }
 
-// Subscribe implements the Subscriber interface
+// Subscribe implements the Subscriber interface. The messages are acked and nacked with ctx, so acks fail
+// once it's done, and Event Store delivers their Events again.
 func (s *persistentSubscription) Subscribe(ctx context.Context) <-chan StreamMessage {
 	stream := make(chan StreamMessage)
 
-	response := struct {
-		Events Events `json:"entries"`
-	}{}
-
 	go func() {
 		defer close(stream)
 
 		for {
-			path := fmt.Sprintf("/subscriptions/%s/%s/%d", s.stream, s.subscriptionName, readCount)
-			res, err := s.slinger.
-				Sling().
-				Get(path).
-				// By default, reading a stream via a persistent subscription will return a
-				// single event per request and will not embed the event properties as part
-				// of the response.
-				Add("Accept", "application/vnd.eventstore.competingatom+json").
-				QueryStruct(&embedParams{
-					Embed: "body",
-				}).
-				ReceiveSuccess(&response)
-			if err != nil {
-				stream <- StreamMessage{
-					Error: err,
-				}
+			events, err := s.poll(ctx)
+			if ctx.Err() != nil {
+				// the poll was cancelled
 				return
 			}
-
-			err = relevantError(res.StatusCode)
 			if err != nil {
+				s.logger.ErrorContext(ctx, "persistent subscription failed", "stream", s.stream, "subscription", s.subscriptionName, "error", err)
 				stream <- StreamMessage{
 					Error: err,
 				}
 				return
 			}
 
-			sort.Sort(response.Events)
-			for _, event := range response.Events {
+			for _, event := range events {
 				select {
 				case <-ctx.Done():
 					return
 				case stream <- StreamMessage{
 					Event: event,
 					Acknowledger: persistentSubscriptionAcknowledger{
+						ctx:              ctx,
 						eventID:          event.ID,
 						stream:           s.stream,
 						subscriptionName: s.subscriptionName,
 						sling:            s.slinger.Sling(),
+						tracer:           s.tracer,
+						traceContext:     eventTraceContext(event),
+						metrics:          s.metrics,
+						logger:           s.logger,
 					},
 				}:
 				}
@@ -226,11 +352,65 @@ func (s *persistentSubscription) Subscribe(ctx context.Context) <-chan StreamMes
 	return stream
 }
 
+// poll reads the next Events of the subscription
+func (s *persistentSubscription) poll(ctx context.Context) (events Events, err error) {
+	ctx, span := s.tracer.Start(ctx, SpanSubscriptionPoll)
+	span.SetAttribute(AttributeStream, s.stream)
+	span.SetAttribute(AttributeSubscription, s.subscriptionName)
+	start := time.Now()
+	var res *http.Response
+	defer func() {
+		span.SetAttribute(AttributeEventCount, len(events))
+		endSpan(span, res, err)
+		s.metrics.ObserveRequest(OperationSubscriptionPoll, time.Since(start), err)
+		if err == nil {
+			s.metrics.EventsRead(s.stream, len(events))
+			s.logger.DebugContext(ctx, "fetched page", "stream", s.stream, "subscription", s.subscriptionName, "events", len(events))
+		}
+	}()
+
+	path := fmt.Sprintf("/subscriptions/%s/%s/%d", s.stream, s.subscriptionName, readCount)
+	req, err := s.slinger.
+		Sling().
+		Get(path).
+		// By default, reading a stream via a persistent subscription will return a
+		// single event per request and will not embed the event properties as part
+		// of the response.
+		Add("Accept", "application/vnd.eventstore.competingatom+json").
+		QueryStruct(&embedParams{
+			Embed: "body",
+		}).
+		Request()
+	if err != nil {
+		return nil, err
+	}
+
+	response := feed{}
+	res, err = s.slinger.Sling().Do(req.WithContext(ctx), &response, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = relevantError(res)
+	if err != nil {
+		return nil, err
+	}
+
+	return response.read(ctx, s.slinger)
+}
+
 type persistentSubscriptionAcknowledger struct {
+	// ctx is the context of the subscription, which acks and nacks are sent with
+	ctx              context.Context
 	eventID          uuid.UUID
 	stream           string
 	subscriptionName string
 	sling            *sling.Sling
+	tracer           Tracer
+	// traceContext is the trace context of the Event, which acks and nacks continue
+	traceContext map[string]string
+	metrics      MetricsCollector
+	logger       Logger
 }
 
 func (a persistentSubscriptionAcknowledger) path() string {
@@ -241,27 +421,77 @@ func (a persistentSubscriptionAcknowledger) path() string {
 }
 
 func (a persistentSubscriptionAcknowledger) Ack() error {
+	ctx, span := a.start(SpanAck)
 	path := a.path()
-	res, err := a.sling.Post(path + "/ack/" + a.eventID.String()).ReceiveSuccess(nil)
-
+	req, err := a.sling.New().Post(path + "/ack/" + a.eventID.String()).Request()
 	if err != nil {
+		endSpan(span, nil, err)
 		return err
 	}
 
-	return relevantError(res.StatusCode)
+	start := time.Now()
+	err = a.do(ctx, span, req)
+	a.metrics.ObserveRequest(OperationAck, time.Since(start), err)
+	if err != nil {
+		a.logger.ErrorContext(ctx, "ack failed", "stream", a.stream, "subscription", a.subscriptionName, "event", a.eventID.String(), "error", err)
+	} else {
+		a.metrics.Acked(a.stream, a.subscriptionName)
+	}
+
+	return err
 }
 
 func (a persistentSubscriptionAcknowledger) Nack(action Action) error {
+	ctx, span := a.start(SpanNack)
+	span.SetAttribute(AttributeAction, string(action))
 	path := a.path()
-	res, err := a.sling.Post(path + "/nack/" + a.eventID.String()).QueryStruct(struct {
+	req, err := a.sling.New().Post(path + "/nack/" + a.eventID.String()).QueryStruct(struct {
 		Action Action `url:"action"`
 	}{
 		Action: action,
-	}).ReceiveSuccess(nil)
+	}).Request()
+	if err != nil {
+		endSpan(span, nil, err)
+		return err
+	}
+
+	start := time.Now()
+	err = a.do(ctx, span, req)
+	a.metrics.ObserveRequest(OperationNack, time.Since(start), err)
+	if err != nil {
+		a.logger.ErrorContext(ctx, "nack failed", "stream", a.stream, "subscription", a.subscriptionName, "event", a.eventID.String(), "action", string(action), "error", err)
+	} else {
+		a.metrics.Nacked(a.stream, a.subscriptionName, action)
+	}
+
+	return err
+}
+
+// start starts the Span of an ack or a nack, in the trace of the Event
+func (a persistentSubscriptionAcknowledger) start(name string) (context.Context, Span) {
+	ctx := a.ctx
+	if len(a.traceContext) > 0 {
+		ctx = a.tracer.Extract(ctx, a.traceContext)
+	}
+
+	ctx, span := a.tracer.Start(ctx, name)
+	span.SetAttribute(AttributeStream, a.stream)
+	span.SetAttribute(AttributeSubscription, a.subscriptionName)
+	return ctx, span
+}
+
+// do sends an ack or a nack, and ends its Span. Acking an Event again has no effect, so they can be
+// retried.
+func (a persistentSubscriptionAcknowledger) do(ctx context.Context, span Span, req *http.Request) (err error) {
+	var res *http.Response
+	defer func() {
+		endSpan(span, res, err)
+	}()
 
+	res, err = a.sling.New().Do(req.WithContext(idempotent(ctx)), nil, nil)
 	if err != nil {
 		return err
 	}
 
-	return relevantError(res.StatusCode)
+	return relevantError(res)
 }
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -13,6 +15,7 @@ import (
 	"github.com/dghubble/sling"
 	"github.com/stretchr/testify/assert"
 	"github.com/vectorhacker/goro"
+	"github.com/vectorhacker/goro/estest"
 )
 
 func TestCatchupSubscription(t *testing.T) {
@@ -172,10 +175,95 @@ func TestPersistentSubscription(t *testing.T) {
 			err := message.Ack()
 			assert.Nil(t, err)
 			events = append(events, message.Event)
+			if len(events) == 3 {
+				break
+			}
 		}
+		assert.Len(t, events, 3)
 
 		assert.True(t, calledAck)
 		assert.True(t, calledCreate)
 		assert.True(t, calledFetch)
 	})
+	t.Run("it should stop a pending poll when the context is done", func(t *testing.T) {
+		mux := pat.New()
+		mux.Put("/subscriptions/{stream}/{subscription_name}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		})
+		mux.Get("/subscriptions/{stream}/{subscription_name}/{count}", func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		})
+		s := httptest.NewServer(mux)
+		defer s.Close()
+
+		subscription, err := goro.NewPersistentSubscription(goro.SlingerFunc(func() *sling.Sling {
+			return sling.New().Base(s.URL).Client(s.Client()).New()
+		}), "test", "testing", goro.PersistentSubscriptionSettings{})
+		assert.Nil(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for message := range subscription.Subscribe(ctx) {
+				t.Errorf("unexpected message %+v", message)
+			}
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the subscription kept polling after its context was done")
+		}
+	})
+	t.Run("it should create the subscription and ack with the caller's context", func(t *testing.T) {
+		type key struct{}
+		s := estest.NewServer()
+		defer s.Close()
+
+		var mu sync.Mutex
+		values := map[string]interface{}{}
+		record := func(next http.RoundTripper) http.RoundTripper {
+			return goro.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				mu.Lock()
+				values[req.Method+" "+strings.SplitN(req.URL.Path, "/ack/", 2)[0]] = req.Context().Value(key{})
+				mu.Unlock()
+				return next.RoundTrip(req)
+			})
+		}
+		client := s.Connect(goro.WithMiddleware(record))
+		assert.Nil(t, client.Writer("tests").Write(context.Background(), goro.ExpectedVersionNone, goro.CreateEvent("test", []byte("{}"), nil, 0)))
+
+		subscription, err := client.PersistentSubscriptionContext(context.WithValue(context.Background(), key{}, "create"), "tests", "group", goro.PersistentSubscriptionSettings{})
+		assert.Nil(t, err)
+
+		ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), key{}, "subscribe"), 5*time.Second)
+		defer cancel()
+		message := <-subscription.Subscribe(ctx)
+		assert.Nil(t, message.Error)
+		assert.Nil(t, message.Ack())
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, "create", values["PUT /subscriptions/tests/group"])
+		assert.Equal(t, "subscribe", values["POST /subscriptions/tests/group"])
+	})
+
+	t.Run("it should update subscriptions wrapped by upcasting and decoding subscribers", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+		client := s.Connect()
+
+		subscription, err := client.PersistentSubscription("tests", "group", goro.PersistentSubscriptionSettings{})
+		assert.Nil(t, err)
+		wrapped := goro.NewDecodingSubscriber(goro.NewEventRegistry(), goro.NewUpcastingSubscriber(goro.NewUpcasters(), subscription))
+
+		updated, err := goro.UpdatePersistentSubscription(wrapped, goro.PersistentSubscriptionSettings{MaxRetryCount: 3})
+		assert.Nil(t, err)
+		assert.Equal(t, wrapped, updated)
+
+		_, err = goro.UpdatePersistentSubscription(goro.NewUpcastingSubscriber(goro.NewUpcasters(), client.CatchupSubscription("tests", 0)), goro.PersistentSubscriptionSettings{})
+		assert.EqualError(t, err, "not a Persistent Subscription")
+	})
 }
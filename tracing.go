@@ -0,0 +1,144 @@
+package goro
+
+import (
+	"context"
+	"net/http"
+)
+
+const traceContextKey = "$goro.traceContext"
+
+// Span names
+const (
+	SpanWrite            = "goro.write"
+	SpanRead             = "goro.read"
+	SpanSubscriptionPoll = "goro.subscription.poll"
+	SpanAck              = "goro.ack"
+	SpanNack             = "goro.nack"
+)
+
+// Span attributes
+const (
+	AttributeStream          = "eventstore.stream"
+	AttributeEventCount      = "eventstore.event_count"
+	AttributeExpectedVersion = "eventstore.expected_version"
+	AttributeSubscription    = "eventstore.subscription"
+	AttributeAction          = "eventstore.action"
+	AttributeStatusCode      = "http.status_code"
+)
+
+// Tracer starts Spans around the requests the Client makes. It follows the shape of OpenTelemetry, so
+// that an adapter for it only has to forward the calls.
+type Tracer interface {
+	// Start starts a Span, as a child of the Span carried by ctx, and returns a copy of ctx that carries it
+	Start(ctx context.Context, name string) (context.Context, Span)
+	// Inject returns the trace context carried by ctx, to be stored in the Metadata of written Events
+	Inject(ctx context.Context) map[string]string
+	// Extract returns a copy of ctx that continues the trace context injected in carrier
+	Extract(ctx context.Context, carrier map[string]string) context.Context
+}
+
+// Span is a traced operation
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+// WithTracer traces the Writes, Read pages, subscription polls, acks and nacks of the Client. The trace
+// context of written Events is stored in their Metadata; ContinueTrace continues it when they are read.
+func WithTracer(tracer Tracer) ClientOption {
+	return func(c *Client) {
+		if tracer == nil {
+			c.invalid("the tracer is nil")
+			return
+		}
+
+		c.tracing = tracer
+	}
+}
+
+// ContinueTrace returns a copy of ctx that continues the trace of the Writer of an Event, if the Event
+// carries one
+func ContinueTrace(ctx context.Context, tracer Tracer, event Event) context.Context {
+	carrier := eventTraceContext(event)
+	if len(carrier) == 0 {
+		return ctx
+	}
+
+	return tracer.Extract(ctx, carrier)
+}
+
+// eventTraceContext returns the trace context stored in the Metadata of an Event
+func eventTraceContext(event Event) map[string]string {
+	carrier := map[string]string{}
+	if found, err := metadataValue(event.Metadata, traceContextKey, &carrier); err != nil || !found {
+		return nil
+	}
+
+	return carrier
+}
+
+// injectTraceContext stores the trace context carried by ctx in the Metadata of events, in place. Events
+// that carry a trace context already, like replicated or imported ones, keep their producer's.
+func injectTraceContext(ctx context.Context, tracer Tracer, events Events) error {
+	carrier := tracer.Inject(ctx)
+	if len(carrier) == 0 {
+		return nil
+	}
+
+	for i, event := range events {
+		metadata, err := setMissingMetadataValue(event.Metadata, traceContextKey, carrier)
+		if err != nil {
+			return err
+		}
+		events[i].Metadata = metadata
+	}
+
+	return nil
+}
+
+// traced is a Slinger that traces its requests, like a Client created with WithTracer
+type traced interface {
+	tracer() Tracer
+}
+
+// tracerOf returns the Tracer of a Slinger, or a Tracer that does nothing
+func tracerOf(slinger Slinger) Tracer {
+	if t, ok := slinger.(traced); ok && t.tracer() != nil {
+		return t.tracer()
+	}
+
+	return noopTracer{}
+}
+
+// endSpan records the status of a response and the error of an operation, and ends the Span
+func endSpan(span Span, res *http.Response, err error) {
+	if res != nil {
+		span.SetAttribute(AttributeStatusCode, res.StatusCode)
+	}
+	if err != nil {
+		span.RecordError(err)
+	}
+
+	span.End()
+}
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+func (noopTracer) Inject(ctx context.Context) map[string]string {
+	return nil
+}
+
+func (noopTracer) Extract(ctx context.Context, carrier map[string]string) context.Context {
+	return ctx
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(key string, value interface{}) {}
+func (noopSpan) RecordError(err error)                      {}
+func (noopSpan) End()                                       {}
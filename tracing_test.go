@@ -0,0 +1,305 @@
+package goro_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vectorhacker/goro"
+	"github.com/vectorhacker/goro/estest"
+	"github.com/vectorhacker/goro/memory"
+)
+
+func testEvents(count int) goro.Events {
+	events := make(goro.Events, count)
+	for i := range events {
+		events[i] = goro.CreateEvent("test", []byte("{}"), nil, int64(i))
+	}
+
+	return events
+}
+
+type spanContextKey struct{}
+
+type spanContext struct {
+	traceID string
+	spanID  string
+}
+
+type recordedSpan struct {
+	mu         sync.Mutex
+	name       string
+	traceID    string
+	spanID     string
+	parentID   string
+	attributes map[string]interface{}
+	err        error
+	ended      bool
+}
+
+func (s *recordedSpan) SetAttribute(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attributes[key] = value
+}
+
+func (s *recordedSpan) RecordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+func (s *recordedSpan) End() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ended = true
+}
+
+// recordingTracer records the Spans it starts, and carries the trace context as "trace/span"
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, goro.Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	span := &recordedSpan{
+		name:       name,
+		traceID:    fmt.Sprintf("trace-%d", len(t.spans)),
+		spanID:     fmt.Sprintf("span-%d", len(t.spans)),
+		attributes: map[string]interface{}{},
+	}
+	if parent, ok := ctx.Value(spanContextKey{}).(spanContext); ok {
+		span.traceID = parent.traceID
+		span.parentID = parent.spanID
+	}
+	t.spans = append(t.spans, span)
+
+	return context.WithValue(ctx, spanContextKey{}, spanContext{traceID: span.traceID, spanID: span.spanID}), span
+}
+
+func (t *recordingTracer) Inject(ctx context.Context) map[string]string {
+	parent, ok := ctx.Value(spanContextKey{}).(spanContext)
+	if !ok {
+		return nil
+	}
+
+	return map[string]string{"traceparent": parent.traceID + "/" + parent.spanID}
+}
+
+func (t *recordingTracer) Extract(ctx context.Context, carrier map[string]string) context.Context {
+	parts := strings.Split(carrier["traceparent"], "/")
+	if len(parts) != 2 {
+		return ctx
+	}
+
+	return context.WithValue(ctx, spanContextKey{}, spanContext{traceID: parts[0], spanID: parts[1]})
+}
+
+// named returns the ended Spans with a name
+func (t *recordingTracer) named(name string) []*recordedSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	spans := []*recordedSpan{}
+	for _, span := range t.spans {
+		span.mu.Lock()
+		if span.name == name && span.ended {
+			spans = append(spans, span)
+		}
+		span.mu.Unlock()
+	}
+
+	return spans
+}
+
+func TestTracing(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("it should trace writes and carry the trace context in the metadata", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+		tracer := &recordingTracer{}
+		client := s.Connect(goro.WithTracer(tracer))
+
+		ctx, parent := tracer.Start(ctx, "handler")
+		err := client.Writer("tests").Write(ctx, goro.ExpectedVersionNone,
+			goro.CreateEvent("test", []byte("{}"), []byte("{\"user\":\"me\"}"), 0),
+			goro.CreateEvent("test", []byte("{}"), nil, 1),
+		)
+		assert.Nil(t, err)
+
+		writes := tracer.named(goro.SpanWrite)
+		assert.Len(t, writes, 1)
+		write := writes[0]
+		assert.Equal(t, parent.(*recordedSpan).spanID, write.parentID)
+		assert.Equal(t, map[string]interface{}{
+			goro.AttributeStream:          "tests",
+			goro.AttributeEventCount:      2,
+			goro.AttributeExpectedVersion: goro.ExpectedVersionNone,
+			goro.AttributeStatusCode:      http.StatusCreated,
+		}, write.attributes)
+
+		events, err := client.FowardsReader("tests").Read(ctx, 0, 2)
+		assert.Nil(t, err)
+		assert.Len(t, events, 2)
+		assert.JSONEq(t, fmt.Sprintf("{\"user\":\"me\",\"$goro.traceContext\":{\"traceparent\":\"%s/%s\"}}", write.traceID, write.spanID), string(events[0].Metadata))
+
+		consumer, span := tracer.Start(goro.ContinueTrace(context.Background(), tracer, events[1]), "consumer")
+		assert.NotNil(t, consumer)
+		assert.Equal(t, write.traceID, span.(*recordedSpan).traceID)
+		assert.Equal(t, write.spanID, span.(*recordedSpan).parentID)
+	})
+
+	t.Run("it should record the errors of writes", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+		tracer := &recordingTracer{}
+		client := s.Connect(goro.WithTracer(tracer))
+
+		assert.Nil(t, client.Writer("tests").Write(ctx, goro.ExpectedVersionNone, goro.CreateEvent("test", []byte("{}"), nil, 0)))
+		err := client.Writer("tests").Write(ctx, goro.ExpectedVersionNone, goro.CreateEvent("test", []byte("{}"), nil, 0))
+		assert.True(t, errors.Is(err, goro.ErrWrongExpectedVersion), "%v", err)
+
+		writes := tracer.named(goro.SpanWrite)
+		assert.Len(t, writes, 2)
+		assert.Equal(t, http.StatusBadRequest, writes[1].attributes[goro.AttributeStatusCode])
+		assert.Equal(t, err, writes[1].err)
+	})
+
+	t.Run("it should trace every page of a read", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+		tracer := &recordingTracer{}
+		client := s.Connect(goro.WithTracer(tracer))
+
+		assert.Nil(t, client.Writer("tests").Write(ctx, goro.ExpectedVersionNone, testEvents(15)...))
+		events, err := client.FowardsReader("tests").Read(ctx, 0, 20)
+		assert.Nil(t, err)
+		assert.Len(t, events, 15)
+
+		reads := tracer.named(goro.SpanRead)
+		assert.Len(t, reads, 2)
+		assert.Equal(t, 10, reads[0].attributes[goro.AttributeEventCount])
+		assert.Equal(t, 5, reads[1].attributes[goro.AttributeEventCount])
+		assert.Equal(t, "tests", reads[1].attributes[goro.AttributeStream])
+		assert.Equal(t, http.StatusOK, reads[1].attributes[goro.AttributeStatusCode])
+
+		_, err = client.FowardsReader("missing").Read(ctx, 0, 1)
+		reads = tracer.named(goro.SpanRead)
+		assert.Len(t, reads, 3)
+		assert.Equal(t, http.StatusNotFound, reads[2].attributes[goro.AttributeStatusCode])
+		assert.Equal(t, err, reads[2].err)
+	})
+
+	t.Run("it should trace catchup subscription polls", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+		tracer := &recordingTracer{}
+		client := s.Connect(goro.WithTracer(tracer))
+
+		assert.Nil(t, client.Writer("tests").Write(ctx, goro.ExpectedVersionNone, testEvents(2)...))
+
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		messages := client.CatchupSubscription("tests", 0).Subscribe(ctx)
+		for i := 0; i < 2; i++ {
+			message := <-messages
+			assert.Nil(t, message.Error)
+		}
+
+		polls := tracer.named(goro.SpanSubscriptionPoll)
+		assert.NotEmpty(t, polls)
+		assert.Equal(t, "tests", polls[0].attributes[goro.AttributeStream])
+		assert.Equal(t, 2, polls[0].attributes[goro.AttributeEventCount])
+	})
+
+	t.Run("it should trace persistent subscription polls, acks and nacks in the trace of the event", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+		tracer := &recordingTracer{}
+		client := s.Connect(goro.WithTracer(tracer))
+
+		assert.Nil(t, client.Writer("tests").Write(ctx, goro.ExpectedVersionNone, testEvents(2)...))
+		write := tracer.named(goro.SpanWrite)[0]
+
+		subscription, err := client.PersistentSubscription("tests", "group", goro.PersistentSubscriptionSettings{})
+		assert.Nil(t, err)
+
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		messages := subscription.Subscribe(ctx)
+
+		message := <-messages
+		assert.Nil(t, message.Error)
+		assert.Nil(t, message.Ack())
+		message = <-messages
+		assert.Nil(t, message.Error)
+		assert.Nil(t, message.Nack(goro.ActionPark))
+
+		polls := tracer.named(goro.SpanSubscriptionPoll)
+		assert.NotEmpty(t, polls)
+		assert.Equal(t, "group", polls[0].attributes[goro.AttributeSubscription])
+
+		acks := tracer.named(goro.SpanAck)
+		assert.Len(t, acks, 1)
+		assert.Equal(t, write.traceID, acks[0].traceID)
+		assert.Equal(t, write.spanID, acks[0].parentID)
+		assert.Equal(t, http.StatusAccepted, acks[0].attributes[goro.AttributeStatusCode])
+
+		nacks := tracer.named(goro.SpanNack)
+		assert.Len(t, nacks, 1)
+		assert.Equal(t, write.traceID, nacks[0].traceID)
+		assert.Equal(t, "park", nacks[0].attributes[goro.AttributeAction])
+	})
+
+	t.Run("it should keep the trace context of replicated events", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+		tracer := &recordingTracer{}
+		client := s.Connect(goro.WithTracer(tracer))
+		source := memory.NewStore()
+		producer := "{\"$goro.traceContext\":{\"traceparent\":\"producer/span\"}}"
+		assert.Nil(t, source.Writer("tests").Write(ctx, goro.ExpectedVersionNone, goro.CreateEvent("test", []byte("{}"), []byte(producer), 0)))
+
+		ctx, cancel := context.WithCancel(ctx)
+		done := make(chan error)
+		go func() {
+			done <- goro.NewReplicator(source, client, "tests").Run(ctx)
+		}()
+		assert.Eventually(t, func() bool {
+			return len(tracer.named(goro.SpanWrite)) == 1
+		}, time.Second, 5*time.Millisecond)
+		cancel()
+		assert.Equal(t, context.Canceled, <-done)
+
+		events, err := client.FowardsReader("tests").Read(context.Background(), 0, 1)
+		assert.Nil(t, err)
+		assert.Len(t, events, 1)
+		assert.JSONEq(t, producer, string(events[0].Metadata))
+	})
+
+	t.Run("it should leave the metadata alone without a tracer", func(t *testing.T) {
+		s := estest.NewServer()
+		defer s.Close()
+		client := s.Connect()
+
+		assert.Nil(t, client.Writer("tests").Write(ctx, goro.ExpectedVersionNone, goro.CreateEvent("test", []byte("{}"), nil, 0)))
+		events, err := client.FowardsReader("tests").Read(ctx, 0, 1)
+		assert.Nil(t, err)
+		assert.Empty(t, events[0].Metadata)
+	})
+
+	t.Run("it should reject a nil tracer", func(t *testing.T) {
+		_, err := goro.NewClient("http://localhost:2113", goro.WithTracer(nil))
+		assert.True(t, errors.Is(err, goro.ErrInvalidOption), "%v", err)
+	})
+}
@@ -0,0 +1,112 @@
+package goro
+
+import (
+	"context"
+)
+
+// EventMapper transforms an Event as it is written or read
+type EventMapper func(event Event) (Event, error)
+
+type mappingWriter struct {
+	mapper EventMapper
+	writer Writer
+}
+
+// NewMappingWriter creates a Writer that maps Events before writing them with another Writer
+func NewMappingWriter(mapper EventMapper, writer Writer) Writer {
+	return &mappingWriter{
+		mapper: mapper,
+		writer: writer,
+	}
+}
+
+// Write implements the Writer interface
+func (w *mappingWriter) Write(ctx context.Context, expectedVersion int64, events ...Event) error {
+	mapped := make(Events, len(events))
+	for i, event := range events {
+		var err error
+		mapped[i], err = w.mapper(event)
+		if err != nil {
+			return err
+		}
+	}
+
+	return w.writer.Write(ctx, expectedVersion, mapped...)
+}
+
+type mappingReader struct {
+	mapper EventMapper
+	reader Reader
+}
+
+// NewMappingReader creates a Reader that maps the Events read by another Reader
+func NewMappingReader(mapper EventMapper, reader Reader) Reader {
+	return &mappingReader{
+		mapper: mapper,
+		reader: reader,
+	}
+}
+
+// Read implements the Reader interface
+func (r *mappingReader) Read(ctx context.Context, start int64, count int) (Events, error) {
+	events, err := r.reader.Read(ctx, start, count)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, event := range events {
+		events[i], err = r.mapper(event)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return events, nil
+}
+
+type mappingSubscriber struct {
+	mapper     EventMapper
+	subscriber Subscriber
+}
+
+// NewMappingSubscriber creates a Subscriber that maps the Events of another Subscriber. A message whose
+// Event can't be mapped carries the error along with the Event and its Acknowledger, and the subscription
+// continues.
+func NewMappingSubscriber(mapper EventMapper, subscriber Subscriber) Subscriber {
+	return &mappingSubscriber{
+		mapper:     mapper,
+		subscriber: subscriber,
+	}
+}
+
+func (s *mappingSubscriber) unwrap() Subscriber {
+	return s.subscriber
+}
+
+// Subscribe implements the Subscriber interface
+func (s *mappingSubscriber) Subscribe(ctx context.Context) <-chan StreamMessage {
+	stream := make(chan StreamMessage)
+
+	go func() {
+		defer close(stream)
+
+		for message := range s.subscriber.Subscribe(ctx) {
+			if message.Error == nil {
+				mapped, err := s.mapper(message.Event)
+				if err != nil {
+					message.Error = err
+				} else {
+					message.Event = mapped
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case stream <- message:
+			}
+		}
+	}()
+
+	return stream
+}
@@ -0,0 +1,120 @@
+package goro
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+const (
+	schemaVersionKey     = "$goro.schemaVersion"
+	defaultSchemaVersion = 1
+)
+
+// SchemaVersion returns the schema version of the data of an Event, which is stored in its Metadata.
+// Events written without a schema version are at version 1.
+func SchemaVersion(event Event) (int, error) {
+	version := defaultSchemaVersion
+	if _, err := metadataValue(event.Metadata, schemaVersionKey, &version); err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// SetSchemaVersion returns a copy of an Event with its schema version set in its Metadata
+func SetSchemaVersion(event Event, version int) (Event, error) {
+	metadata, err := setMetadataValue(event.Metadata, schemaVersionKey, version)
+	if err != nil {
+		return Event{}, err
+	}
+
+	event.Metadata = metadata
+	return event, nil
+}
+
+// Upcaster transforms the data of an Event from one schema version into the next one
+type Upcaster func(data json.RawMessage) (json.RawMessage, error)
+
+type upcasterKey struct {
+	eventType string
+	version   int
+}
+
+// Upcasters transforms the data of old Events into the current shape of their type, one schema version
+// at a time, so that streams stay valid without rewriting them.
+type Upcasters struct {
+	mu        sync.RWMutex
+	upcasters map[upcasterKey]Upcaster
+}
+
+// NewUpcasters creates an empty set of Upcasters
+func NewUpcasters() *Upcasters {
+	return &Upcasters{
+		upcasters: map[upcasterKey]Upcaster{},
+	}
+}
+
+// Register adds an Upcaster that transforms the data of an Event type from version into version+1
+func (u *Upcasters) Register(eventType string, version int, upcaster Upcaster) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.upcasters[upcasterKey{eventType: eventType, version: version}] = upcaster
+}
+
+// CurrentVersion returns the schema version Events of a type are upcast to
+func (u *Upcasters) CurrentVersion(eventType string) int {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	version := defaultSchemaVersion
+	for {
+		if _, ok := u.upcasters[upcasterKey{eventType: eventType, version: version}]; !ok {
+			return version
+		}
+		version++
+	}
+}
+
+// Upcast applies the Upcasters registered for the type of an Event, starting at its schema version, until
+// it reaches the current version. The returned Event has its schema version updated.
+func (u *Upcasters) Upcast(event Event) (Event, error) {
+	version, err := SchemaVersion(event)
+	if err != nil {
+		return Event{}, err
+	}
+
+	from := version
+	for {
+		u.mu.RLock()
+		upcaster, ok := u.upcasters[upcasterKey{eventType: event.Type, version: version}]
+		u.mu.RUnlock()
+		if !ok {
+			break
+		}
+
+		event.Data, err = upcaster(event.Data)
+		if err != nil {
+			return Event{}, err
+		}
+		version++
+	}
+
+	if version == from {
+		return event, nil
+	}
+
+	return SetSchemaVersion(event, version)
+}
+
+// NewUpcastingReader creates a Reader that upcasts the Events read by another Reader
+func NewUpcastingReader(upcasters *Upcasters, reader Reader) Reader {
+	return NewMappingReader(upcasters.Upcast, reader)
+}
+
+// NewUpcastingSubscriber creates a Subscriber that upcasts the Events of another Subscriber. A message
+// whose Event can't be upcast carries the error along with the Event and its Acknowledger, and the
+// subscription continues.
+func NewUpcastingSubscriber(upcasters *Upcasters, subscriber Subscriber) Subscriber {
+	return NewMappingSubscriber(upcasters.Upcast, subscriber)
+}
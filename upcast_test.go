@@ -0,0 +1,110 @@
+package goro_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vectorhacker/goro"
+)
+
+type readerFunc func(ctx context.Context, start int64, count int) (goro.Events, error)
+
+func (f readerFunc) Read(ctx context.Context, start int64, count int) (goro.Events, error) {
+	return f(ctx, start, count)
+}
+
+func TestUpcasters(t *testing.T) {
+	upcasters := goro.NewUpcasters()
+	// version 1 had the amount in cents as a string
+	upcasters.Register("deposited", 1, func(data json.RawMessage) (json.RawMessage, error) {
+		v1 := struct {
+			Cents string `json:"cents"`
+		}{}
+		if err := json.Unmarshal(data, &v1); err != nil {
+			return nil, err
+		}
+
+		return json.Marshal(map[string]string{"amount": v1.Cents})
+	})
+	// version 2 had the amount as a string
+	upcasters.Register("deposited", 2, func(data json.RawMessage) (json.RawMessage, error) {
+		v2 := struct {
+			Amount json.Number `json:"amount"`
+		}{}
+		if err := json.Unmarshal(data, &v2); err != nil {
+			return nil, err
+		}
+
+		return json.Marshal(map[string]json.Number{"amount": v2.Amount})
+	})
+
+	t.Run("it should know the current version of a type", func(t *testing.T) {
+		assert.Equal(t, 3, upcasters.CurrentVersion("deposited"))
+		assert.Equal(t, 1, upcasters.CurrentVersion("withdrawn"))
+	})
+
+	t.Run("it should upcast events without a schema version", func(t *testing.T) {
+		event := goro.CreateEvent("deposited", []byte("{\"cents\":\"10\"}"), nil, 0)
+
+		upcast, err := upcasters.Upcast(event)
+		assert.Nil(t, err)
+		assert.JSONEq(t, "{\"amount\":10}", string(upcast.Data))
+
+		version, err := goro.SchemaVersion(upcast)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, version)
+	})
+
+	t.Run("it should upcast from the schema version in the metadata", func(t *testing.T) {
+		event := goro.CreateEvent("deposited", []byte("{\"amount\":\"10\"}"), []byte("{\"$goro.schemaVersion\":2,\"user\":\"me\"}"), 0)
+
+		upcast, err := upcasters.Upcast(event)
+		assert.Nil(t, err)
+		assert.JSONEq(t, "{\"amount\":10}", string(upcast.Data))
+		assert.JSONEq(t, "{\"$goro.schemaVersion\":3,\"user\":\"me\"}", string(upcast.Metadata))
+	})
+
+	t.Run("it should leave current events alone", func(t *testing.T) {
+		event, err := goro.SetSchemaVersion(goro.CreateEvent("deposited", []byte("{\"amount\":10}"), nil, 0), 3)
+		assert.Nil(t, err)
+
+		upcast, err := upcasters.Upcast(event)
+		assert.Nil(t, err)
+		assert.Equal(t, event, upcast)
+	})
+
+	t.Run("it should upcast the events of a Reader", func(t *testing.T) {
+		reader := goro.NewUpcastingReader(upcasters, readerFunc(func(ctx context.Context, start int64, count int) (goro.Events, error) {
+			return goro.Events{
+				goro.CreateEvent("deposited", []byte("{\"cents\":\"10\"}"), nil, 0),
+			}, nil
+		}))
+
+		events, err := reader.Read(context.Background(), 0, 1)
+		assert.Nil(t, err)
+		assert.Len(t, events, 1)
+		assert.JSONEq(t, "{\"amount\":10}", string(events[0].Data))
+	})
+
+	t.Run("it should upcast the messages of a Subscriber", func(t *testing.T) {
+		subscriber := goro.NewUpcastingSubscriber(upcasters, subscriberFunc(func(ctx context.Context) <-chan goro.StreamMessage {
+			stream := make(chan goro.StreamMessage, 2)
+			stream <- goro.StreamMessage{Event: goro.CreateEvent("deposited", []byte("{\"cents\":\"10\"}"), nil, 0)}
+			stream <- goro.StreamMessage{Event: goro.CreateEvent("deposited", []byte("{\"cents\":10}"), nil, 0)}
+			close(stream)
+			return stream
+		}))
+
+		messages := []goro.StreamMessage{}
+		for message := range subscriber.Subscribe(context.Background()) {
+			messages = append(messages, message)
+		}
+
+		assert.Len(t, messages, 2)
+		assert.Nil(t, messages[0].Error)
+		assert.JSONEq(t, "{\"amount\":10}", string(messages[0].Event.Data))
+		assert.NotNil(t, messages[1].Error)
+	})
+}
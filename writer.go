@@ -5,17 +5,25 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"sort"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
 )
 
 type streamWriter struct {
 	stream  string
 	slinger Slinger
+	tracer  Tracer
+	metrics MetricsCollector
 }
 
 const (
-	writePath        = "/streams/%s"
-	eventContentType = "application/vnd.eventstore.events+json"
+	writePath         = "/streams/%s"
+	eventContentType  = "application/vnd.eventstore.events+json"
+	jsonContentType   = "application/json"
+	binaryContentType = "application/octet-stream"
 )
 
 // NewWriter creates a new Writer for a stream
@@ -23,11 +31,32 @@ func NewWriter(slinger Slinger, stream string) Writer {
 	return &streamWriter{
 		stream:  stream,
 		slinger: slinger,
+		tracer:  tracerOf(slinger),
+		metrics: metricsOf(slinger),
 	}
 }
 
-// Write implements the Writer interface. It writes events in a bulk after sorting them in version order
-func (w streamWriter) Write(ctx context.Context, expectedVersion int64, events ...Event) error {
+// Write implements the Writer interface. It writes events in a bulk after sorting them in version order.
+// The correlation and causation ids and the trace context carried by ctx are added to the Metadata of the
+// events. Writes are only retried by a RetryPolicy when every event has an id.
+// Binary events can't be written in a bulk, so when there are any, the events are written one at a time
+// through the single event endpoint, with their Metadata in the ES-EventMetadata header. Such a Write
+// isn't atomic: when it fails, the events before the failing one stay written. Writing the same events
+// again is safe when every event has an id, because Event Store ignores the ones it already has.
+func (w streamWriter) Write(ctx context.Context, expectedVersion int64, events ...Event) (err error) {
+	ctx, span := w.tracer.Start(ctx, SpanWrite)
+	span.SetAttribute(AttributeStream, w.stream)
+	span.SetAttribute(AttributeEventCount, len(events))
+	span.SetAttribute(AttributeExpectedVersion, expectedVersion)
+	start := time.Now()
+	defer func() {
+		endSpan(span, nil, err)
+		w.metrics.ObserveRequest(OperationWrite, time.Since(start), err)
+		if err == nil {
+			w.metrics.EventsWritten(w.stream, len(events))
+		}
+	}()
+
 	b := new(bytes.Buffer)
 
 	path := fmt.Sprintf(writePath, w.stream)
@@ -35,6 +64,25 @@ func (w streamWriter) Write(ctx context.Context, expectedVersion int64, events .
 	data := append(Events{}, events...)
 	sort.Sort(data)
 
+	if err := correlate(ctx, data); err != nil {
+		return err
+	}
+
+	if err := injectTraceContext(ctx, w.tracer, data); err != nil {
+		return err
+	}
+
+	// Event Store ignores Events it already has, so writes can be retried when every Event has an id
+	if fixedIDs(data) {
+		ctx = idempotent(ctx)
+	}
+
+	for _, event := range data {
+		if event.Binary {
+			return w.writeEach(ctx, span, expectedVersion, data)
+		}
+	}
+
 	if err := json.NewEncoder(b).Encode(data); err != nil {
 		return err
 	}
@@ -50,12 +98,81 @@ func (w streamWriter) Write(ctx context.Context, expectedVersion int64, events .
 		return err
 	}
 
+	return w.do(ctx, span, req)
+}
+
+// writeEach writes events one at a time, with the raw data as the body and the type, id and metadata as
+// headers
+func (w streamWriter) writeEach(ctx context.Context, span Span, expectedVersion int64, events Events) error {
+	path := fmt.Sprintf(writePath, w.stream)
+
+	for i, event := range events {
+		contentType := jsonContentType
+		if event.Binary {
+			contentType = binaryContentType
+		}
+
+		version := expectedVersion
+		if expectedVersion != ExpectedVersionAny {
+			version += int64(i)
+		}
+
+		s := w.slinger.
+			Sling().
+			Post(path).
+			Body(bytes.NewReader(event.Data)).
+			Set("Content-Type", contentType).
+			Set("ES-EventType", event.Type).
+			Set("ES-EventId", event.ID.String()).
+			Set("ES-ExpectedVersion", fmt.Sprintf("%d", version))
+
+		if len(event.Metadata) > 0 {
+			// headers can't span lines
+			metadata := new(bytes.Buffer)
+			if err := json.Compact(metadata, event.Metadata); err != nil {
+				return err
+			}
+			s = s.Set("ES-EventMetadata", metadata.String())
+		}
+
+		req, err := s.Request()
+		if err != nil {
+			return err
+		}
+
+		if err := w.do(ctx, span, req); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// do sends a write, and records the status of the response in the Span of the Write
+func (w streamWriter) do(ctx context.Context, span Span, req *http.Request) error {
 	req = req.WithContext(ctx)
 
 	resp, err := w.slinger.Sling().Do(req, nil, nil)
 	if err != nil {
 		return err
 	}
+	span.SetAttribute(AttributeStatusCode, resp.StatusCode)
+
+	// Event Store signals a concurrency conflict with a 400 that carries the current version of the stream
+	if resp.StatusCode == http.StatusBadRequest && resp.Header.Get("ES-CurrentVersion") != "" {
+		return newHTTPError(resp, ErrWrongExpectedVersion)
+	}
+
+	return relevantError(resp)
+}
+
+// fixedIDs reports whether every Event has an id
+func fixedIDs(events Events) bool {
+	for _, event := range events {
+		if uuid.Equal(event.ID, uuid.Nil) {
+			return false
+		}
+	}
 
-	return relevantError(resp.StatusCode)
+	return true
 }
@@ -3,6 +3,7 @@ package goro_test
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -58,4 +59,56 @@ func TestWriter(t *testing.T) {
 		err := w.Write(ctx, goro.ExpectedVersionAny, evnt1, evnt2)
 		assert.Nil(t, err)
 	})
+
+	t.Run("it should write binary events one at a time", func(t *testing.T) {
+		evnt1 := goro.Event{
+			Data:   []byte{0x01, 0x02},
+			Type:   "binaryevent",
+			ID:     goro.NewUUID(),
+			Binary: true,
+		}
+		evnt2 := goro.Event{
+			Data:     []byte("{\"key\":\"value\"}"),
+			Metadata: []byte("{\n  \"user\": \"me\"\n}"),
+			Type:     "testevent",
+			Version:  1,
+			ID:       goro.NewUUID(),
+		}
+
+		writes := 0
+		mux := pat.New()
+		mux.Post("/streams/{stream}", func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			assert.Nil(t, err)
+
+			switch writes {
+			case 0:
+				assert.Equal(t, "application/octet-stream", r.Header.Get("Content-Type"))
+				assert.Equal(t, "binaryevent", r.Header.Get("ES-EventType"))
+				assert.Equal(t, evnt1.ID.String(), r.Header.Get("ES-EventId"))
+				assert.Equal(t, "4", r.Header.Get("ES-ExpectedVersion"))
+				assert.Empty(t, r.Header.Get("ES-EventMetadata"))
+				assert.Equal(t, []byte(evnt1.Data), body)
+			case 1:
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+				assert.Equal(t, "testevent", r.Header.Get("ES-EventType"))
+				assert.Equal(t, "5", r.Header.Get("ES-ExpectedVersion"))
+				assert.Equal(t, "{\"user\":\"me\"}", r.Header.Get("ES-EventMetadata"))
+				assert.Equal(t, []byte(evnt2.Data), body)
+			}
+
+			writes++
+			w.WriteHeader(http.StatusCreated)
+		})
+		s := httptest.NewServer(mux)
+		defer s.Close()
+
+		w := goro.NewWriter(goro.SlingerFunc(func() *sling.Sling {
+			return sling.New().Base(s.URL).Client(s.Client())
+		}), "test")
+
+		err := w.Write(context.Background(), 4, evnt1, evnt2)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, writes)
+	})
 }